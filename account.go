@@ -1,6 +1,7 @@
 package atto
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math/big"
@@ -25,8 +26,9 @@ type Account struct {
 }
 
 type blockInfo struct {
-	Error    string `json:"error"`
-	Contents Block  `json:"contents"`
+	Error     string          `json:"error"`
+	Confirmed string          `json:"confirmed"`
+	Contents  json.RawMessage `json:"contents"`
 }
 
 // NewAccount creates a new Account and populates both its fields.
@@ -37,7 +39,7 @@ func NewAccount(privateKey *big.Int) (a Account, err error) {
 }
 
 // NewAccountFromAddress creates a new Account and populates both its
-// fields.
+// fields. If address is invalid, an *InvalidAddressError is returned.
 func NewAccountFromAddress(address string) (a Account, err error) {
 	a.Address = address
 	a.PublicKey, err = getPublicKeyFromAddress(address)
@@ -56,7 +58,19 @@ func derivePublicKey(privateKey *big.Int) *big.Int {
 
 func getAddress(publicKey *big.Int) (string, error) {
 	base32PublicKey := base32Encode(publicKey)
+	checksum, err := getAddressChecksum(publicKey)
+	if err != nil {
+		return "", err
+	}
+	address := "nano_" +
+		strings.Repeat("1", 52-len(base32PublicKey)) + base32PublicKey +
+		checksum
+	return address, nil
+}
 
+// getAddressChecksum returns the base32 encoded checksum, that makes
+// up the last eight characters of an address.
+func getAddressChecksum(publicKey *big.Int) (string, error) {
 	hasher, err := blake2b.New(5, nil)
 	if err != nil {
 		return "", err
@@ -67,11 +81,7 @@ func getAddress(publicKey *big.Int) (string, error) {
 	}
 	hashBytes := hasher.Sum(nil)
 	base32Hash := base32Encode(big.NewInt(0).SetBytes(revertBytes(hashBytes)))
-
-	address := "nano_" +
-		strings.Repeat("1", 52-len(base32PublicKey)) + base32PublicKey +
-		strings.Repeat("1", 8-len(base32Hash)) + base32Hash
-	return address, nil
+	return strings.Repeat("1", 8-len(base32Hash)) + base32Hash, nil
 }
 
 // FetchAccountInfo fetches the AccountInfo of Account from the given
@@ -80,20 +90,32 @@ func getAddress(publicKey *big.Int) (string, error) {
 // It is also verified, that the retreived AccountInfo is valid by
 // doing a block_info RPC for the frontier, verifying the signature
 // and ensuring that no fields have been changed in the account_info
-// response.
+// response. If the frontier is a legacy block, only the fields it
+// contains can be verified: the representative of open and change
+// blocks and the balance of send blocks.
 //
 // May return ErrAccountNotFound or ErrAccountManipulated.
 //
 // If ErrAccountNotFound is returned, FirstReceive can be used to
 // create a first Block and AccountInfo and create the account by then
 // submitting this Block.
-func (a Account) FetchAccountInfo(node string) (i AccountInfo, err error) {
+func (a Account) FetchAccountInfo(node string) (AccountInfo, error) {
+	return a.fetchAccountInfo(context.Background(), nodeClient(node))
+}
+
+// FetchAccountInfoContext is like FetchAccountInfo, but aborts when ctx
+// is done.
+func (a Account) FetchAccountInfoContext(ctx context.Context, node string) (AccountInfo, error) {
+	return a.fetchAccountInfo(ctx, nodeClient(node))
+}
+
+func (a Account) fetchAccountInfo(ctx context.Context, c *Client) (i AccountInfo, err error) {
 	requestBody := fmt.Sprintf(`{`+
 		`"action": "account_info",`+
 		`"account": "%s",`+
 		`"representative": "true"`+
 		`}`, a.Address)
-	responseBytes, err := doRPC(requestBody, node)
+	responseBytes, err := c.doRPC(ctx, requestBody)
 	if err != nil {
 		return
 	}
@@ -109,20 +131,21 @@ func (a Account) FetchAccountInfo(node string) (i AccountInfo, err error) {
 	} else {
 		i.PublicKey = a.PublicKey
 		i.Address = a.Address
-		err = a.verifyInfo(i, node)
+		err = a.verifyInfo(ctx, i, c)
 	}
 	return
 }
 
 // verifyInfo gets the frontier block of info, ensures that Hash,
-// Representative and Balance match and verifies it's signature.
-func (a Account) verifyInfo(info AccountInfo, node string) error {
+// Representative and Balance match and verifies it's signature. The
+// frontier may be a state block or a legacy block.
+func (a Account) verifyInfo(ctx context.Context, info AccountInfo, c *Client) error {
 	requestBody := fmt.Sprintf(`{`+
 		`"action": "block_info",`+
 		`"json_block": "true",`+
 		`"hash": "%s"`+
 		`}`, info.Frontier)
-	responseBytes, err := doRPC(requestBody, node)
+	responseBytes, err := c.doRPC(ctx, requestBody)
 	if err != nil {
 		return err
 	}
@@ -130,31 +153,75 @@ func (a Account) verifyInfo(info AccountInfo, node string) error {
 	if err = json.Unmarshal(responseBytes, &block); err != nil {
 		return err
 	}
-	if info.Error != "" {
-		return fmt.Errorf("could not get block info: %s", info.Error)
+	if block.Error != "" {
+		return fmt.Errorf("could not get block info: %s", block.Error)
+	}
+	frontier, err := ParseBlock(block.Contents)
+	if err != nil {
+		return err
+	}
+	if err = a.verifyBlock(frontier, info.Frontier); err != nil {
+		return err
 	}
-	hash, err := block.Contents.Hash()
+	return verifyFrontierFields(frontier, info.Representative, info.Balance)
+}
+
+// verifyFrontierFields ensures that representative and balance match
+// those of frontier. Legacy blocks only contain some of these fields;
+// the others cannot be verified using the frontier alone.
+func verifyFrontierFields(frontier AnyBlock, representative string, balance Amount) error {
+	var blockRepresentative string
+	switch b := frontier.(type) {
+	case Block:
+		blockRepresentative = b.Representative
+	case LegacyBlock:
+		if b.Type == "open" || b.Type == "change" {
+			blockRepresentative = b.Representative
+		}
+	}
+	if blockRepresentative != "" && !sameAccount(blockRepresentative, representative) {
+		return ErrAccountManipulated
+	}
+	blockBalance, ok, err := blockBalance(frontier)
 	if err != nil {
 		return err
 	}
-	if err = block.Contents.verifySignature(a); err == errInvalidSignature ||
-		info.Frontier != hash ||
-		info.Representative != block.Contents.Representative ||
-		info.Balance != block.Contents.Balance {
+	if ok && blockBalance.Cmp(balance) != 0 {
 		return ErrAccountManipulated
 	}
-	return err
+	return nil
+}
+
+// sameAccount reports whether the addresses a and b belong to the same
+// public key, regardless of their prefixes.
+func sameAccount(a, b string) bool {
+	keyA, err := addressKey(a)
+	if err != nil {
+		return false
+	}
+	keyB, err := addressKey(b)
+	return err == nil && keyA == keyB
 }
 
 // FetchReceivable fetches all unreceived blocks of Account from node.
 func (a Account) FetchReceivable(node string) ([]Receivable, error) {
+	return a.fetchReceivable(context.Background(), nodeClient(node))
+}
+
+// FetchReceivableContext is like FetchReceivable, but aborts when ctx
+// is done.
+func (a Account) FetchReceivableContext(ctx context.Context, node string) ([]Receivable, error) {
+	return a.fetchReceivable(ctx, nodeClient(node))
+}
+
+func (a Account) fetchReceivable(ctx context.Context, c *Client) ([]Receivable, error) {
 	requestBody := fmt.Sprintf(`{`+
 		`"action": "receivable", `+
 		`"account": "%s", `+
 		`"include_only_confirmed": "true", `+
 		`"source": "true"`+
 		`}`, a.Address)
-	responseBytes, err := doRPC(requestBody, node)
+	responseBytes, err := c.doRPC(ctx, requestBody)
 	if err != nil {
 		return nil, err
 	}
@@ -165,7 +232,11 @@ func (a Account) FetchReceivable(node string) ([]Receivable, error) {
 	if err == nil && receivable.Error != "" {
 		err = fmt.Errorf("could not fetch unreceived sends: %s", receivable.Error)
 	}
-	return internalReceivableToReceivable(receivable), err
+	receivables := internalReceivableToReceivable(receivable)
+	if err == nil && c.VerifyReceivables {
+		err = a.verifyReceivables(ctx, c, receivables)
+	}
+	return receivables, err
 }
 
 // FirstReceive creates the first receive block of an account. The block
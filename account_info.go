@@ -3,7 +3,6 @@ package atto
 import (
 	"fmt"
 	"math/big"
-	"strings"
 )
 
 // AccountInfo holds the basic data needed for Block creation.
@@ -14,7 +13,7 @@ type AccountInfo struct {
 
 	Frontier       string `json:"frontier"`
 	Representative string `json:"representative"`
-	Balance        string `json:"balance"`
+	Balance        Amount `json:"balance"`
 
 	PublicKey *big.Int `json:"-"`
 	Address   string   `json:"-"`
@@ -22,11 +21,19 @@ type AccountInfo struct {
 
 // Send creates a send block, which will still be missing its signature
 // and work. The Frontier and Balance of the AccountInfo will be
-// updated. The amount is interpreted as Nano, not raw!
-func (i *AccountInfo) Send(amount, toAddr string) (Block, error) {
-	balance, err := getBalanceAfterSend(i.Balance, amount)
-	if err != nil {
+// updated.
+//
+// May return ErrZeroAmount, ErrInsufficientBalance or an error
+// wrapping ErrInvalidAmount.
+func (i *AccountInfo) Send(amount Amount, toAddr string) (Block, error) {
+	if err := amount.validate(); err != nil {
+		return Block{}, err
+	} else if amount.IsZero() {
+		return Block{}, ErrZeroAmount
+	} else if err = i.Balance.validate(); err != nil {
 		return Block{}, err
+	} else if amount.Cmp(i.Balance) > 0 {
+		return Block{}, ErrInsufficientBalance
 	}
 	recipientNumber, err := getPublicKeyFromAddress(toAddr)
 	if err != nil {
@@ -39,7 +46,7 @@ func (i *AccountInfo) Send(amount, toAddr string) (Block, error) {
 		Account:        i.Address,
 		Previous:       i.Frontier,
 		Representative: i.Representative,
-		Balance:        balance.String(),
+		Balance:        i.Balance.Sub(amount),
 		Link:           fmt.Sprintf("%064X", recipientBytes),
 	}
 	hash, err := block.Hash()
@@ -51,34 +58,6 @@ func (i *AccountInfo) Send(amount, toAddr string) (Block, error) {
 	return block, err
 }
 
-func getBalanceAfterSend(oldBalance string, amount string) (*big.Int, error) {
-	balance, ok := big.NewInt(0).SetString(oldBalance, 10)
-	if !ok {
-		err := fmt.Errorf("cannot parse '%s' as an integer", oldBalance)
-		return nil, err
-	}
-	amountRaw, err := nanoToRaw(amount)
-	if err != nil {
-		return nil, err
-	}
-	return balance.Sub(balance, amountRaw), nil
-}
-
-func nanoToRaw(amountString string) (*big.Int, error) {
-	i := strings.Index(amountString, ".")
-	missingZerosUntilRaw := 30
-	if i > -1 {
-		missingZerosUntilRaw = 31 + i - len(amountString)
-		amountString = amountString[:i] + amountString[i+1:] // Remove "."
-	}
-	amountString += strings.Repeat("0", missingZerosUntilRaw)
-	amount, ok := big.NewInt(0).SetString(amountString, 10)
-	if !ok {
-		return nil, fmt.Errorf("cannot parse '%s' as an interger", amountString)
-	}
-	return amount, nil
-}
-
 // Change creates a change block, which will still be missing its
 // signature and work. The Frontier and Representative of the
 // AccountInfo will be updated.
@@ -100,32 +79,32 @@ func (i *AccountInfo) Change(representative string) (Block, error) {
 	return block, err
 }
 
+// SendAll creates a send block, which transfers the entire balance to
+// toAddr. See Send for details.
+func (i *AccountInfo) SendAll(toAddr string) (Block, error) {
+	return i.Send(i.Balance, toAddr)
+}
+
 // Receive creates a receive block, which will still be missing its
 // signature and work. The Frontier and Balance of the AccountInfo will
 // be updated.
+//
+// May return ErrZeroAmount or an error wrapping ErrInvalidAmount.
 func (i *AccountInfo) Receive(receivable Receivable) (Block, error) {
-	updatedBalance, ok := big.NewInt(0).SetString(i.Balance, 10)
-	if !ok {
-		err := fmt.Errorf("cannot parse '%s' as an integer", i.Balance)
-		return Block{}, err
-	}
-	amount, ok := big.NewInt(0).SetString(receivable.Amount, 10)
-	if !ok {
-		err := fmt.Errorf("cannot parse '%s' as an integer", receivable.Amount)
+	if err := receivable.Amount.validate(); err != nil {
 		return Block{}, err
-	}
-	if amount.Sign() < 1 {
-		err := fmt.Errorf("amount '%s' is not positive", receivable.Amount)
+	} else if receivable.Amount.IsZero() {
+		return Block{}, ErrZeroAmount
+	} else if err = i.Balance.Add(receivable.Amount).validate(); err != nil {
 		return Block{}, err
 	}
-	updatedBalance.Add(updatedBalance, amount)
 	block := Block{
 		Type:           "state",
 		SubType:        SubTypeReceive,
 		Account:        i.Address,
 		Previous:       i.Frontier,
 		Representative: i.Representative,
-		Balance:        updatedBalance.String(),
+		Balance:        i.Balance.Add(receivable.Amount),
 		Link:           receivable.Hash,
 	}
 	hash, err := block.Hash()
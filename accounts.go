@@ -0,0 +1,489 @@
+package atto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// defaultBatchSize is the maximum number of accounts or hashes sent in
+// a single RPC, if Client.BatchSize is not set. Many public nodes
+// reject bigger requests.
+const defaultBatchSize = 500
+
+// AccountBalance is the balance of an account, as reported by a node.
+type AccountBalance struct {
+	Balance    Amount
+	Receivable Amount
+}
+
+type accountsBalances struct {
+	Error    string                           `json:"error"`
+	Balances map[string]accountsBalancesEntry `json:"balances"`
+	Errors   map[string]string                `json:"errors"`
+}
+
+type accountsBalancesEntry struct {
+	Balance    Amount `json:"balance"`
+	Receivable Amount `json:"receivable"`
+
+	// Pending is sent instead of Receivable by older nodes.
+	Pending Amount `json:"pending"`
+}
+
+type accountsRepresentatives struct {
+	Error           string            `json:"error"`
+	Representatives map[string]string `json:"representatives"`
+	Errors          map[string]string `json:"errors"`
+}
+
+type accountsFrontiers struct {
+	Error     string            `json:"error"`
+	Frontiers map[string]string `json:"frontiers"`
+	Errors    map[string]string `json:"errors"`
+}
+
+type accountsReceivable struct {
+	Error  string                   `json:"error"`
+	Blocks accountsReceivableBlocks `json:"blocks"`
+}
+
+type accountsReceivableBlocks map[string]receivableBlocks
+
+// UnmarshalJSON interprets an empty string as an empty map, like
+// receivableBlocks.UnmarshalJSON does.
+func (b *accountsReceivableBlocks) UnmarshalJSON(in []byte) error {
+	if string(in) == `""` {
+		return nil
+	}
+	var raw map[string]receivableBlocks
+	err := json.Unmarshal(in, &raw)
+	*b = accountsReceivableBlocks(raw)
+	return err
+}
+
+// FetchBalances fetches the balances and receivable amounts of
+// accounts using the accounts_balances RPC. The result has the same
+// order as accounts. Accounts that have not been opened yet have a
+// zero Balance.
+//
+// Unlike FetchAccountInfo, the balances are not verified, so they
+// should only be used for display purposes. Use FetchAccountInfos to
+// get verified balances.
+func (c *Client) FetchBalances(accounts []Account) ([]AccountBalance, error) {
+	return c.fetchBalances(context.Background(), accounts)
+}
+
+// FetchBalancesContext is like FetchBalances, but aborts when ctx is
+// done.
+func (c *Client) FetchBalancesContext(ctx context.Context, accounts []Account) ([]AccountBalance, error) {
+	return c.fetchBalances(ctx, accounts)
+}
+
+// FetchRepresentatives fetches the representatives of accounts using
+// the accounts_representatives RPC. The result has the same order as
+// accounts. The representative of an account, that has not been
+// opened yet, is empty. Older nodes, which reject the whole request if
+// any account has not been opened, cause an error wrapping
+// ErrAccountNotFound.
+//
+// Unlike FetchAccountInfo, the representatives are not verified, so
+// they should only be used for display purposes.
+func (c *Client) FetchRepresentatives(accounts []Account) ([]string, error) {
+	return c.fetchRepresentatives(context.Background(), accounts)
+}
+
+// FetchRepresentativesContext is like FetchRepresentatives, but aborts
+// when ctx is done.
+func (c *Client) FetchRepresentativesContext(ctx context.Context, accounts []Account) ([]string, error) {
+	return c.fetchRepresentatives(ctx, accounts)
+}
+
+// FetchFrontiers fetches the frontiers of accounts using the
+// accounts_frontiers RPC. The result has the same order as accounts.
+// The frontier of an account, that has not been opened yet, is empty.
+//
+// The frontiers are not verified. Use FetchAccountInfos to get
+// verified frontiers.
+func (c *Client) FetchFrontiers(accounts []Account) ([]string, error) {
+	return c.fetchFrontiers(context.Background(), accounts)
+}
+
+// FetchFrontiersContext is like FetchFrontiers, but aborts when ctx is
+// done.
+func (c *Client) FetchFrontiersContext(ctx context.Context, accounts []Account) ([]string, error) {
+	return c.fetchFrontiers(ctx, accounts)
+}
+
+// FetchAccountInfos fetches the AccountInfo of all accounts. The result
+// has the same order as accounts. The Frontier of accounts, that have
+// not been opened yet, is empty.
+//
+// Like FetchAccountInfo, the frontier blocks are verified. Their hashes
+// and signatures are checked and the Balance and Representative are
+// taken from the verified blocks. Instead of two RPCs per account,
+// only an accounts_frontiers and a blocks_info RPC per batch are used.
+//
+// May return ErrAccountManipulated.
+func (c *Client) FetchAccountInfos(accounts []Account) ([]AccountInfo, error) {
+	return c.fetchAccountInfos(context.Background(), accounts)
+}
+
+// FetchAccountInfosContext is like FetchAccountInfos, but aborts when
+// ctx is done.
+func (c *Client) FetchAccountInfosContext(ctx context.Context, accounts []Account) ([]AccountInfo, error) {
+	return c.fetchAccountInfos(ctx, accounts)
+}
+
+// FetchReceivables fetches the unreceived blocks of all accounts using
+// the accounts_receivable RPC. The result has the same order as
+// accounts. If c.VerifyReceivables is true, all receivables are
+// verified and ErrAccountManipulated is returned if any of them has
+// been manipulated.
+func (c *Client) FetchReceivables(accounts []Account) ([][]Receivable, error) {
+	return c.fetchReceivables(context.Background(), accounts)
+}
+
+// FetchReceivablesContext is like FetchReceivables, but aborts when ctx
+// is done.
+func (c *Client) FetchReceivablesContext(ctx context.Context, accounts []Account) ([][]Receivable, error) {
+	return c.fetchReceivables(ctx, accounts)
+}
+
+// FetchBlocks fetches the blocks with the given hashes using the
+// blocks_info RPC. The result has the same order as hashes and contains
+// a Block for state blocks or a LegacyBlock for legacy blocks.
+//
+// It is verified, that the hash of each block matches the requested
+// hash; otherwise ErrAccountManipulated is returned. Signatures are
+// not verified, since the owners of the blocks are not known.
+func (c *Client) FetchBlocks(hashes []string) ([]AnyBlock, error) {
+	return c.fetchBlocks(context.Background(), hashes)
+}
+
+// FetchBlocksContext is like FetchBlocks, but aborts when ctx is done.
+func (c *Client) FetchBlocksContext(ctx context.Context, hashes []string) ([]AnyBlock, error) {
+	return c.fetchBlocks(ctx, hashes)
+}
+
+func (c *Client) fetchBalances(ctx context.Context, accounts []Account) ([]AccountBalance, error) {
+	balances := make([]AccountBalance, len(accounts))
+	err := c.forEachBatch(len(accounts), func(start, end int) error {
+		var response accountsBalances
+		err := c.doAccountsRPC(ctx, "accounts_balances", accounts[start:end], &response)
+		if err != nil {
+			return err
+		}
+		if response.Error != "" {
+			return fmt.Errorf("could not fetch balances: %s", response.Error)
+		}
+		entries := make(map[string]accountsBalancesEntry, len(response.Balances))
+		for address, entry := range response.Balances {
+			key, err := addressKey(address)
+			if err != nil {
+				return err
+			}
+			entries[key] = entry
+		}
+		errors := make(map[string]string, len(response.Errors))
+		for address, msg := range response.Errors {
+			key, err := addressKey(address)
+			if err != nil {
+				return err
+			}
+			errors[key] = msg
+		}
+		for i := start; i < end; i++ {
+			entry, ok := entries[publicKeyString(accounts[i])]
+			if !ok {
+				msg := errors[publicKeyString(accounts[i])]
+				if msg == "Account not found" {
+					continue
+				} else if msg == "" {
+					msg = "missing from response"
+				}
+				return fmt.Errorf("could not fetch balance of %s: %s", accounts[i].Address, msg)
+			}
+			balances[i].Balance = entry.Balance
+			balances[i].Receivable = entry.Receivable
+			if entry.Receivable.IsZero() {
+				balances[i].Receivable = entry.Pending
+			}
+		}
+		return nil
+	})
+	return balances, err
+}
+
+func (c *Client) fetchRepresentatives(ctx context.Context, accounts []Account) ([]string, error) {
+	representatives := make([]string, len(accounts))
+	err := c.forEachBatch(len(accounts), func(start, end int) error {
+		var response accountsRepresentatives
+		err := c.doAccountsRPC(ctx, "accounts_representatives", accounts[start:end], &response)
+		if err != nil {
+			return err
+		}
+		// Older nodes fail entirely, if any account has not been opened.
+		// Then the representatives of the other accounts are unknown, so
+		// only the errors of individual accounts can be tolerated.
+		if response.Error == "Account not found" {
+			return fmt.Errorf("could not fetch representatives: %w", ErrAccountNotFound)
+		} else if response.Error != "" {
+			return fmt.Errorf("could not fetch representatives: %s", response.Error)
+		}
+		entries := make(map[string]string, len(response.Representatives))
+		for address, representative := range response.Representatives {
+			key, err := addressKey(address)
+			if err != nil {
+				return err
+			}
+			entries[key] = representative
+		}
+		errors := make(map[string]string, len(response.Errors))
+		for address, msg := range response.Errors {
+			key, err := addressKey(address)
+			if err != nil {
+				return err
+			}
+			errors[key] = msg
+		}
+		for i := start; i < end; i++ {
+			key := publicKeyString(accounts[i])
+			representative, ok := entries[key]
+			if !ok {
+				msg := errors[key]
+				if msg == "Account not found" {
+					continue
+				} else if msg == "" {
+					msg = "missing from response"
+				}
+				return fmt.Errorf("could not fetch representative of %s: %s", accounts[i].Address, msg)
+			}
+			representatives[i] = representative
+		}
+		return nil
+	})
+	return representatives, err
+}
+
+func (c *Client) fetchFrontiers(ctx context.Context, accounts []Account) ([]string, error) {
+	frontiers := make([]string, len(accounts))
+	err := c.forEachBatch(len(accounts), func(start, end int) error {
+		var response accountsFrontiers
+		err := c.doAccountsRPC(ctx, "accounts_frontiers", accounts[start:end], &response)
+		if err != nil {
+			return err
+		}
+		if response.Error != "" {
+			return fmt.Errorf("could not fetch frontiers: %s", response.Error)
+		}
+		entries := make(map[string]string, len(response.Frontiers))
+		for address, frontier := range response.Frontiers {
+			key, err := addressKey(address)
+			if err != nil {
+				return err
+			}
+			entries[key] = frontier
+		}
+		for i := start; i < end; i++ {
+			frontiers[i] = entries[publicKeyString(accounts[i])]
+		}
+		return nil
+	})
+	return frontiers, err
+}
+
+func (c *Client) fetchAccountInfos(ctx context.Context, accounts []Account) ([]AccountInfo, error) {
+	frontiers, err := c.fetchFrontiers(ctx, accounts)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, 0, len(frontiers))
+	for _, frontier := range frontiers {
+		if frontier != "" {
+			hashes = append(hashes, frontier)
+		}
+	}
+	blocks, err := c.fetchBlocksInfo(ctx, hashes)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]AccountInfo, len(accounts))
+	for i, account := range accounts {
+		infos[i].PublicKey = account.PublicKey
+		infos[i].Address = account.Address
+		if frontiers[i] == "" {
+			continue
+		}
+		block, ok := blocks.Blocks[frontiers[i]]
+		if !ok {
+			return nil, ErrAccountManipulated
+		}
+		if err = account.verifyBlock(block.Contents, frontiers[i]); err != nil {
+			return nil, err
+		}
+		frontier, ok := block.Contents.(Block)
+		if !ok {
+			// A legacy frontier does not contain the balance and
+			// representative, so account_info is needed.
+			if infos[i], err = account.fetchAccountInfo(ctx, c); err != nil {
+				return nil, err
+			}
+			if infos[i].Frontier != frontiers[i] {
+				return nil, ErrAccountManipulated
+			}
+			continue
+		}
+		infos[i].Frontier = frontiers[i]
+		infos[i].Representative = frontier.Representative
+		infos[i].Balance = frontier.Balance
+	}
+	return infos, nil
+}
+
+// verifyBlock ensures that hash is the hash of block and that block
+// has been signed by a.
+func (a Account) verifyBlock(block AnyBlock, hash string) error {
+	actual, err := block.Hash()
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(actual, hash) {
+		return ErrAccountManipulated
+	}
+	if valid, err := block.VerifySignature(a); err != nil {
+		return err
+	} else if !valid {
+		return ErrAccountManipulated
+	}
+	return nil
+}
+
+func (c *Client) fetchReceivables(ctx context.Context, accounts []Account) ([][]Receivable, error) {
+	receivables := make([][]Receivable, len(accounts))
+	err := c.forEachBatch(len(accounts), func(start, end int) error {
+		request := struct {
+			Action               string   `json:"action"`
+			Accounts             []string `json:"accounts"`
+			IncludeOnlyConfirmed string   `json:"include_only_confirmed"`
+			Source               string   `json:"source"`
+		}{"accounts_receivable", addresses(accounts[start:end]), "true", "true"}
+		var response accountsReceivable
+		if err := c.doBatchRPC(ctx, request, &response); err != nil {
+			return err
+		}
+		if response.Error != "" {
+			return fmt.Errorf("could not fetch unreceived sends: %s", response.Error)
+		}
+		entries := make(map[string]receivableBlocks, len(response.Blocks))
+		for address, blocks := range response.Blocks {
+			key, err := addressKey(address)
+			if err != nil {
+				return err
+			}
+			entries[key] = blocks
+		}
+		for i := start; i < end; i++ {
+			blocks := entries[publicKeyString(accounts[i])]
+			receivables[i] = internalReceivableToReceivable(internalReceivable{Blocks: blocks})
+		}
+		return nil
+	})
+	if err != nil || !c.VerifyReceivables {
+		return receivables, err
+	}
+	return receivables, c.verifyReceivables(ctx, accounts, receivables)
+}
+
+func (c *Client) fetchBlocks(ctx context.Context, hashes []string) ([]AnyBlock, error) {
+	info, err := c.fetchBlocksInfo(ctx, hashes)
+	if err != nil {
+		return nil, err
+	}
+	blocks := make([]AnyBlock, len(hashes))
+	for i, hash := range hashes {
+		item, ok := info.Blocks[hash]
+		if !ok {
+			return nil, ErrAccountManipulated
+		}
+		actual, err := item.Contents.Hash()
+		if err != nil {
+			return nil, err
+		}
+		if !strings.EqualFold(actual, hash) {
+			return nil, ErrAccountManipulated
+		}
+		blocks[i] = item.Contents
+	}
+	return blocks, nil
+}
+
+// forEachBatch splits n items into batches of at most c.BatchSize
+// items and calls f with the bounds of each batch.
+func (c *Client) forEachBatch(n int, f func(start, end int) error) error {
+	size := c.BatchSize
+	if size <= 0 {
+		size = defaultBatchSize
+	}
+	for start := 0; start < n; start += size {
+		end := start + size
+		if end > n {
+			end = n
+		}
+		if err := f(start, end); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// doAccountsRPC sends an RPC, which takes a list of accounts, and
+// unmarshals the response into v.
+func (c *Client) doAccountsRPC(ctx context.Context, action string, accounts []Account, v interface{}) error {
+	request := struct {
+		Action   string   `json:"action"`
+		Accounts []string `json:"accounts"`
+	}{action, addresses(accounts)}
+	return c.doBatchRPC(ctx, request, v)
+}
+
+// doBatchRPC sends request, encoded as JSON, and unmarshals the
+// response into v.
+func (c *Client) doBatchRPC(ctx context.Context, request interface{}, v interface{}) error {
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	responseBytes, err := c.doRPC(ctx, string(requestBody))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(responseBytes, v)
+}
+
+// addresses returns the addresses of accounts.
+func addresses(accounts []Account) []string {
+	res := make([]string, len(accounts))
+	for i, account := range accounts {
+		res[i] = account.Address
+	}
+	return res
+}
+
+// addressKey returns the hexadecimal public key of address. The node
+// may use a different prefix for addresses in its responses than the
+// one used in the request, so responses must be matched by public key.
+func addressKey(address string) (string, error) {
+	account, err := NewAccountFromAddress(address)
+	if err != nil {
+		return "", err
+	}
+	return publicKeyString(account), nil
+}
+
+// publicKeyString returns the hexadecimal representation of a's public
+// key.
+func publicKeyString(a Account) string {
+	return fmt.Sprintf("%064X", bigIntToBytes(a.PublicKey, 32))
+}
@@ -0,0 +1,231 @@
+package atto
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetchBalances(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The node answers with xrb_ prefixes and an unopened account.
+		w.Write([]byte(`{
+			"balances": {"xrb_3t6k35gi95xu6tergt6p69ck76ogmitsa8mnijtpxm9fkcm736xtoncuohr3": {"balance": "10", "pending": "2"}},
+			"errors": {"nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh": "Account not found"}
+		}`))
+	}))
+	defer server.Close()
+	var accounts []Account
+	for _, address := range []string{
+		"nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh",
+		"nano_3t6k35gi95xu6tergt6p69ck76ogmitsa8mnijtpxm9fkcm736xtoncuohr3",
+	} {
+		account, err := NewAccountFromAddress(address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		accounts = append(accounts, account)
+	}
+	balances, err := NewClient(server.URL).FetchBalances(accounts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !balances[0].Balance.IsZero() || !balances[0].Receivable.IsZero() {
+		t.Errorf("expected zero balance for unopened account, got %+v", balances[0])
+	}
+	if balances[1].Balance.String() != "10" || balances[1].Receivable.String() != "2" {
+		t.Errorf("expected balance 10 and receivable 2, got %+v", balances[1])
+	}
+}
+
+func TestFetchRepresentatives(t *testing.T) {
+	response := `{
+		"representatives": {"xrb_3t6k35gi95xu6tergt6p69ck76ogmitsa8mnijtpxm9fkcm736xtoncuohr3": "nano_3t6k35gi95xu6tergt6p69ck76ogmitsa8mnijtpxm9fkcm736xtoncuohr3"},
+		"errors": {"nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh": "Account not found"}
+	}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+	var accounts []Account
+	for _, address := range []string{
+		"nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh",
+		"nano_3t6k35gi95xu6tergt6p69ck76ogmitsa8mnijtpxm9fkcm736xtoncuohr3",
+	} {
+		account, err := NewAccountFromAddress(address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		accounts = append(accounts, account)
+	}
+	client := NewClient(server.URL)
+	representatives, err := client.FetchRepresentatives(accounts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if representatives[0] != "" || representatives[1] != accounts[1].Address {
+		t.Errorf("unexpected representatives %v", representatives)
+	}
+
+	// Older nodes fail for the whole batch.
+	response = `{"error": "Account not found"}`
+	if _, err = client.FetchRepresentatives(accounts); !errors.Is(err, ErrAccountNotFound) {
+		t.Errorf("expected %v, got %v", ErrAccountNotFound, err)
+	}
+	response = `{"representatives": {}}`
+	if _, err = client.FetchRepresentatives(accounts); err == nil {
+		t.Error("expected error for missing representatives")
+	}
+}
+
+func TestDiscoverAccounts(t *testing.T) {
+	const seed = "D420296F5FEF486175FAA8F649DED00A5B0A096DB8D03972937542C51A7F296C"
+	address := func(index uint32) string {
+		privateKey, err := NewPrivateKey(seed, index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		account, err := NewAccount(privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return account.Address
+	}
+	opened, receiving := address(1), address(3)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Action   string   `json:"action"`
+			Accounts []string `json:"accounts"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		switch request.Action {
+		case "accounts_frontiers":
+			fmt.Fprintf(w, `{"frontiers": {"%s": "AB"}}`, opened)
+		case "accounts_balances":
+			balances := make(map[string]AccountBalance)
+			for _, account := range request.Accounts {
+				balances[account] = AccountBalance{}
+				if account == receiving {
+					balances[account] = AccountBalance{Receivable: NewAmount(big.NewInt(1))}
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"balances": balances})
+		}
+	}))
+	defer server.Close()
+	discovered, err := NewClient(server.URL).DiscoverAccounts(seed, DiscoveryOptions{Gap: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(discovered) != 2 || discovered[0].Index != 1 || discovered[1].Index != 3 {
+		t.Errorf("expected accounts 1 and 3, got %+v", discovered)
+	}
+}
+
+func TestFetchAccountInfos(t *testing.T) {
+	chain := newFakeChain(t)
+	unopened, err := NewAccountFromAddress("nano_3t6k35gi95xu6tergt6p69ck76ogmitsa8mnijtpxm9fkcm736xtoncuohr3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	frontier := chain.blocks[0]
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var request struct {
+			Action   string   `json:"action"`
+			Accounts []string `json:"accounts"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		switch request.Action {
+		case "accounts_frontiers":
+			frontiers := make(map[string]string)
+			for _, account := range request.Accounts {
+				if account == chain.account.Address {
+					frontiers[account] = frontier.Hash
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"frontiers": frontiers})
+		case "blocks_info":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"blocks": map[string]interface{}{frontier.Hash: map[string]interface{}{"contents": frontier.Block}},
+			})
+		}
+	}))
+	defer server.Close()
+	client := NewClient(server.URL)
+	client.BatchSize = 1
+	infos, err := client.FetchAccountInfos([]Account{unopened, chain.account})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 3 {
+		t.Errorf("expected 3 requests with a batch size of 1, got %d", requests)
+	}
+	if infos[0].Frontier != "" || infos[1].Frontier != frontier.Hash ||
+		infos[1].Balance.Cmp(frontier.Block.(Block).Balance) != 0 {
+		t.Errorf("unexpected account infos: %+v", infos)
+	}
+
+	// Report a frontier, that does not belong to the block.
+	frontier.Hash = chain.blocks[1].Hash
+	if _, err = client.FetchAccountInfos([]Account{chain.account}); err != ErrAccountManipulated {
+		t.Errorf("expected %v, got %v", ErrAccountManipulated, err)
+	}
+}
+
+func TestFetchReceivables(t *testing.T) {
+	chain := newFakeChain(t)
+	receiver := chain.sender // The newest block of chain sends to it.
+	send := chain.blocks[0]
+	blocks := make(map[string]AnyBlock)
+	for _, block := range chain.blocks {
+		blocks[block.Hash] = block.Block
+	}
+	blocksInfoRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Action   string   `json:"action"`
+			Accounts []string `json:"accounts"`
+			Hashes   []string `json:"hashes"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		switch request.Action {
+		case "accounts_receivable":
+			fmt.Fprintf(w, `{"blocks": {"%s": {"%s": {"amount": "%s", "source": "%s"}}}}`,
+				receiver.Address, send.Hash, send.Amount, chain.account.Address)
+		case "blocks_info":
+			blocksInfoRequests++
+			items := make(map[string]interface{})
+			for _, hash := range request.Hashes {
+				items[hash] = map[string]interface{}{"contents": blocks[hash]}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"blocks": items})
+		}
+	}))
+	defer server.Close()
+	client := NewClient(server.URL)
+	client.VerifyReceivables = true
+	receivables, err := client.FetchReceivables([]Account{receiver, receiver, receiver})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blocksInfoRequests != 2 {
+		t.Errorf("expected 2 blocks_info requests, got %d", blocksInfoRequests)
+	}
+	for i := range receivables {
+		if len(receivables[i]) != 1 || receivables[i][0].Hash != send.Hash {
+			t.Errorf("unexpected receivables for account %d: %+v", i, receivables[i])
+		}
+	}
+
+	// The send block does not belong to the claimed source.
+	blocks[send.Hash] = chain.blocks[1].Block
+	if _, err = client.FetchReceivables([]Account{receiver}); err != ErrAccountManipulated {
+		t.Errorf("expected %v, got %v", ErrAccountManipulated, err)
+	}
+}
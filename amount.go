@@ -0,0 +1,199 @@
+package atto
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ErrInvalidAmount is used when an amount is malformed, negative or
+// larger than the total supply of Nano permits.
+var ErrInvalidAmount = fmt.Errorf("invalid amount")
+
+// ErrZeroAmount is used when an amount of zero is given, where a
+// positive amount is required.
+var ErrZeroAmount = fmt.Errorf("amount is zero")
+
+// ErrInsufficientBalance is used when an amount exceeds the available
+// balance.
+var ErrInsufficientBalance = fmt.Errorf("insufficient balance")
+
+// maxRawBits is the number of bits available for balances in blocks.
+const maxRawBits = 128
+
+// Unit is a unit of Nano. Its value is the power of ten, which
+// converts the unit to raw.
+//
+// The units are named after the historical prefixes of the nano node,
+// in which 10^24 raw was called "nano". Because today "Nano" commonly
+// means Mnano, that unit is called SmallNano here.
+type Unit int
+
+const (
+	// Raw is the smallest unit of Nano.
+	Raw Unit = 0
+
+	// SmallNano is 10^24 raw, the unit historically called "nano" by
+	// the nano node. It is not the everyday "Nano"; that is Mnano.
+	SmallNano Unit = 24
+
+	// Knano is 10^27 raw.
+	Knano Unit = 27
+
+	// Mnano is 10^30 raw. It is the unit commonly called "Nano" or
+	// "NANO".
+	Mnano Unit = 30
+)
+
+// Amount is an amount of Nano with a precision of one raw. The zero
+// value is zero raw. Amounts are immutable; all operations return new
+// Amounts.
+type Amount struct {
+	raw *big.Int // nil means zero.
+}
+
+// NewAmount creates an Amount of raw raw.
+func NewAmount(raw *big.Int) Amount {
+	return Amount{big.NewInt(0).Set(raw)}
+}
+
+// ParseAmount parses s, which is given in unit. s must be a decimal
+// number without a sign, that may have up to unit fractional digits.
+//
+// Errors wrap ErrInvalidAmount, so errors.Is can be used to detect
+// them.
+func ParseAmount(s string, unit Unit) (Amount, error) {
+	integerPart, fractionalPart := s, ""
+	if i := strings.Index(s, "."); i > -1 {
+		integerPart, fractionalPart = s[:i], s[i+1:]
+	}
+	if integerPart == "" && fractionalPart == "" ||
+		!isDecimal(integerPart) || !isDecimal(fractionalPart) {
+		return Amount{}, fmt.Errorf("%w: cannot parse '%s'", ErrInvalidAmount, s)
+	}
+	if len(fractionalPart) > int(unit) {
+		return Amount{}, fmt.Errorf("%w: '%s' has more than %d fractional digits", ErrInvalidAmount, s, unit)
+	}
+	digits := integerPart + fractionalPart + strings.Repeat("0", int(unit)-len(fractionalPart))
+	raw, ok := big.NewInt(0).SetString(digits, 10)
+	if !ok {
+		return Amount{}, fmt.Errorf("%w: cannot parse '%s'", ErrInvalidAmount, s)
+	}
+	amount := Amount{raw}
+	return amount, amount.validate()
+}
+
+// isDecimal reports whether s only consists of the digits 0 to 9.
+func isDecimal(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseRaw parses s as a decimal number of raw, like it is used by the
+// node.
+func parseRaw(s string) (Amount, error) {
+	if s == "" || !isDecimal(s) {
+		return Amount{}, fmt.Errorf("%w: cannot parse '%s' as raw", ErrInvalidAmount, s)
+	}
+	raw, ok := big.NewInt(0).SetString(s, 10)
+	if !ok {
+		return Amount{}, fmt.Errorf("%w: cannot parse '%s' as raw", ErrInvalidAmount, s)
+	}
+	amount := Amount{raw}
+	return amount, amount.validate()
+}
+
+// validate ensures that a is neither negative nor too large to be
+// used as the balance of a block.
+func (a Amount) validate() error {
+	if a.Sign() < 0 {
+		return fmt.Errorf("%w: %s raw is negative", ErrInvalidAmount, a)
+	} else if a.bigInt().BitLen() > maxRawBits {
+		return fmt.Errorf("%w: %s raw is too large", ErrInvalidAmount, a)
+	}
+	return nil
+}
+
+func (a Amount) bigInt() *big.Int {
+	if a.raw == nil {
+		return big.NewInt(0)
+	}
+	return a.raw
+}
+
+// Raw returns a copy of the amount in raw.
+func (a Amount) Raw() *big.Int {
+	return big.NewInt(0).Set(a.bigInt())
+}
+
+// String returns the amount in raw, as a decimal number.
+func (a Amount) String() string {
+	return a.bigInt().String()
+}
+
+// Text returns the exact amount in unit, without trailing zeros in
+// the fractional part.
+func (a Amount) Text(unit Unit) string {
+	divisor := big.NewInt(0).Exp(big.NewInt(10), big.NewInt(int64(unit)), nil)
+	absRaw := big.NewInt(0).Abs(a.bigInt())
+	integerDigits, fractionalDigits := big.NewInt(0).QuoRem(absRaw, divisor, big.NewInt(0))
+	res := integerDigits.String()
+	if fractionalDigits.Sign() != 0 {
+		fractionalDigitsString := fmt.Sprintf("%0*s", int(unit), fractionalDigits.String())
+		res += "." + strings.TrimRight(fractionalDigitsString, "0")
+	}
+	if a.Sign() < 0 {
+		return "-" + res
+	}
+	return res
+}
+
+// Add returns a+b.
+func (a Amount) Add(b Amount) Amount {
+	return Amount{big.NewInt(0).Add(a.bigInt(), b.bigInt())}
+}
+
+// Sub returns a-b.
+func (a Amount) Sub(b Amount) Amount {
+	return Amount{big.NewInt(0).Sub(a.bigInt(), b.bigInt())}
+}
+
+// Cmp compares a and b and returns -1 if a < b, 0 if a == b and +1 if
+// a > b.
+func (a Amount) Cmp(b Amount) int {
+	return a.bigInt().Cmp(b.bigInt())
+}
+
+// Sign returns -1 if a < 0, 0 if a == 0 and +1 if a > 0.
+func (a Amount) Sign() int {
+	return a.bigInt().Sign()
+}
+
+// IsZero reports whether a is zero.
+func (a Amount) IsZero() bool {
+	return a.Sign() == 0
+}
+
+// MarshalJSON encodes a as a string of raw, like the node does.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON decodes a string of raw, like it is sent by the node.
+func (a *Amount) UnmarshalJSON(in []byte) error {
+	var s string
+	if err := json.Unmarshal(in, &s); err != nil {
+		return err
+	}
+	amount, err := parseRaw(s)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
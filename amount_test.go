@@ -0,0 +1,89 @@
+package atto
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in   string
+		unit Unit
+		raw  string
+	}{
+		{"1", Mnano, "1000000000000000000000000000000"},
+		{"0.1", Mnano, "100000000000000000000000000000"},
+		{"1.", Mnano, "1000000000000000000000000000000"},
+		{".5", Knano, "500000000000000000000000000"},
+		{"2", SmallNano, "2000000000000000000000000"},
+		{"0.000000000000000000000000000001", Mnano, "1"},
+		{"42", Raw, "42"},
+	}
+	for _, test := range tests {
+		amount, err := ParseAmount(test.in, test.unit)
+		if err != nil {
+			t.Errorf("could not parse '%s': %v", test.in, err)
+		} else if amount.String() != test.raw {
+			t.Errorf("expected '%s' to be %s raw, got %s", test.in, test.raw, amount)
+		}
+	}
+	for _, in := range []string{"", ".", "1.2.3", "-1", "+1", "1e3", "0.0000000000000000000000000000001", "340282367"} {
+		if _, err := ParseAmount(in, Mnano); err == nil {
+			t.Errorf("expected error when parsing '%s'", in)
+		}
+	}
+}
+
+func TestAmountText(t *testing.T) {
+	amount, _ := ParseAmount("1.025", Mnano)
+	if text := amount.Text(Mnano); text != "1.025" {
+		t.Errorf("expected 1.025, got %s", text)
+	}
+	if text := amount.Text(Knano); text != "1025" {
+		t.Errorf("expected 1025, got %s", text)
+	}
+	if text := (Amount{}).Sub(amount).Text(Mnano); text != "-1.025" {
+		t.Errorf("expected -1.025, got %s", text)
+	}
+}
+
+func TestAmountJSON(t *testing.T) {
+	var amount Amount
+	if err := json.Unmarshal([]byte(`"1000"`), &amount); err != nil {
+		t.Fatal(err)
+	}
+	out, err := json.Marshal(amount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `"1000"` {
+		t.Errorf(`expected "1000", got %s`, out)
+	}
+}
+
+func TestSendAmountValidation(t *testing.T) {
+	const recipient = "nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh"
+	info := AccountInfo{
+		Frontier:       "991CF190094C00F0B68E2E5F75F6BEE95A2E0BD93CEAA4A6734DB9F19B728948",
+		Representative: recipient,
+		Address:        recipient,
+		Balance:        mustParseAmount(t, "1", Mnano),
+	}
+	tests := []struct {
+		amount Amount
+		err    error
+	}{
+		{Amount{}, ErrZeroAmount},
+		{Amount{}.Sub(mustParseAmount(t, "1", Raw)), ErrInvalidAmount},
+		{mustParseAmount(t, "1.000000000000000000000000000001", Mnano), ErrInsufficientBalance},
+	}
+	for _, test := range tests {
+		if _, err := info.Send(test.amount, recipient); !errors.Is(err, test.err) {
+			t.Errorf("sending %s raw: expected %v, got %v", test.amount, test.err, err)
+		}
+	}
+	if _, err := info.Send(mustParseAmount(t, "1", Mnano), recipient); err != nil {
+		t.Errorf("could not send the entire balance: %v", err)
+	}
+}
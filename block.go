@@ -1,6 +1,7 @@
 package atto
 
 import (
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -20,10 +21,37 @@ var ErrSignatureMissing = fmt.Errorf("signature is missing")
 // required for the attempted operation.
 var ErrWorkMissing = fmt.Errorf("work is missing")
 
-var (
-	// See https://docs.nano.org/integration-guides/work-generation/#difficulty-thresholds
-	defaultWorkThreshold uint64 = 0xfffffff800000000
-	receiveWorkThreshold uint64 = 0xfffffe0000000000
+// ErrInsufficientWork is used when the Work of a Block does not reach
+// the required difficulty threshold. Errors of type
+// *InsufficientWorkError wrap it, so errors.Is can be used to detect
+// them.
+var ErrInsufficientWork = fmt.Errorf("work is insufficient")
+
+// InsufficientWorkError describes by how much the work of a Block
+// missed its threshold.
+type InsufficientWorkError struct {
+	Difficulty uint64
+	Threshold  uint64
+}
+
+func (e *InsufficientWorkError) Error() string {
+	return fmt.Sprintf("work difficulty %016x is below threshold %016x", e.Difficulty, e.Threshold)
+}
+
+// Unwrap returns ErrInsufficientWork.
+func (e *InsufficientWorkError) Unwrap() error {
+	return ErrInsufficientWork
+}
+
+// See https://docs.nano.org/integration-guides/work-generation/#difficulty-thresholds
+const (
+	// DefaultWorkThreshold is the threshold for the work of send and
+	// change blocks.
+	DefaultWorkThreshold uint64 = 0xfffffff800000000
+
+	// ReceiveWorkThreshold is the threshold for the work of receive
+	// and epoch blocks.
+	ReceiveWorkThreshold uint64 = 0xfffffe0000000000
 )
 
 // BlockSubType represents the sub-type of a block.
@@ -38,6 +66,11 @@ const (
 
 	// SubTypeSend denotes blocks which lower the balance.
 	SubTypeSend
+
+	// SubTypeEpoch denotes epoch blocks, which upgrade an account to a
+	// new protocol version. They are signed by the epoch signer
+	// instead of the account's owner.
+	SubTypeEpoch
 )
 
 // Block represents a block in the block chain of an account.
@@ -46,7 +79,7 @@ type Block struct {
 	Account        string `json:"account"`
 	Previous       string `json:"previous"`
 	Representative string `json:"representative"`
-	Balance        string `json:"balance"`
+	Balance        Amount `json:"balance"`
 	Link           string `json:"link"`
 	Signature      string `json:"signature"`
 	Work           string `json:"work"`
@@ -79,7 +112,17 @@ func (b *Block) Sign(privateKey *big.Int) error {
 	return nil
 }
 
-func (b *Block) verifySignature(a Account) (err error) {
+// verifySignature verifies the signature of b against the public key
+// of a. Epoch blocks may also be signed by the signer of their epoch.
+func (b *Block) verifySignature(a Account) error {
+	err := b.verifySignatureOf(a.PublicKey)
+	if err == errInvalidSignature && b.EpochVersion() != 0 {
+		return b.verifyEpochSignature()
+	}
+	return err
+}
+
+func (b *Block) verifySignatureOf(publicKey *big.Int) (err error) {
 	sig, ok := big.NewInt(0).SetString(b.Signature, 16)
 	if !ok {
 		return fmt.Errorf("cannot parse '%s' as an integer", b.Signature)
@@ -88,28 +131,41 @@ func (b *Block) verifySignature(a Account) (err error) {
 	if err != nil {
 		return err
 	}
-	if !isValidSignature(a.PublicKey, hash, bigIntToBytes(sig, 64)) {
+	if !isValidSignature(publicKey, hash, bigIntToBytes(sig, 64)) {
 		err = errInvalidSignature
 	}
 	return
 }
 
 // FetchWork uses the generate_work RPC on node to fetch and then set
-// the Work of b.
+// the Work of b. The received work is validated locally and an
+// *InsufficientWorkError is returned if it does not reach the required
+// threshold; b.Work is left unchanged in this case.
 func (b *Block) FetchWork(node string) error {
+	return b.fetchWork(context.Background(), nodeClient(node), 0)
+}
+
+// FetchWorkContext is like FetchWork, but aborts when ctx is done.
+func (b *Block) FetchWorkContext(ctx context.Context, node string) error {
+	return b.fetchWork(ctx, nodeClient(node), 0)
+}
+
+// fetchWork fetches work, that reaches difficulty or the threshold of
+// b, whichever is higher.
+func (b *Block) fetchWork(ctx context.Context, c *Client, difficulty uint64) error {
 	hash, err := b.workHash()
 	if err != nil {
 		return err
 	}
+	difficulty = b.minimumDifficulty(difficulty)
 
 	requestBody := fmt.Sprintf(`{"action":"work_generate", "hash":"%s"`, hash)
-	if b.SubType == SubTypeReceive {
-		// Receive blocks need less work, so lower the difficulty.
-		requestBody += fmt.Sprintf(`, "difficulty":"%016x"`, receiveWorkThreshold)
+	if difficulty != DefaultWorkThreshold {
+		requestBody += fmt.Sprintf(`, "difficulty":"%016x"`, difficulty)
 	}
 	requestBody += `}`
 
-	responseBytes, err := doRPC(requestBody, node)
+	responseBytes, err := c.doRPC(ctx, requestBody)
 	if err != nil {
 		return err
 	}
@@ -122,6 +178,13 @@ func (b *Block) FetchWork(node string) error {
 	if response.Error != "" {
 		return fmt.Errorf("could not get work for block: %s", response.Error)
 	}
+
+	// Don't trust the node; it could be misbehaving or overloaded.
+	candidate := *b
+	candidate.Work = response.Work
+	if err = candidate.validateWorkDifficulty(difficulty); err != nil {
+		return err
+	}
 	b.Work = response.Work
 	return nil
 }
@@ -129,27 +192,97 @@ func (b *Block) FetchWork(node string) error {
 // GenerateWork uses the CPU of the local computer to generate work and
 // then sets it as b.Work.
 func (b *Block) GenerateWork() error {
-	hashString, err := b.workHash()
+	return b.GenerateWorkContext(context.Background())
+}
+
+// GenerateWorkContext is like GenerateWork, but aborts when ctx is
+// done. In this case ctx.Err() is returned.
+func (b *Block) GenerateWorkContext(ctx context.Context) error {
+	return b.GenerateWorkOptions(ctx, WorkOptions{})
+}
+
+// GenerateWorkOptions is like GenerateWorkContext, but the CPU usage,
+// duration and progress reporting of the search can be controlled with
+// options.
+func (b *Block) GenerateWorkOptions(ctx context.Context, options WorkOptions) error {
+	hash, err := b.workHash()
 	if err != nil {
 		return err
 	}
-	hash, err := hex.DecodeString(hashString)
+	work, err := GenerateWorkForRoot(ctx, hash, b.minimumDifficulty(options.Difficulty), options)
 	if err != nil {
 		return err
 	}
-	workThreshold := defaultWorkThreshold
-	if b.SubType == SubTypeReceive {
-		// Receive blocks need less work, so lower the difficulty.
-		workThreshold = receiveWorkThreshold
+	b.Work = work
+	return nil
+}
+
+// Difficulty computes the difficulty of b.Work locally. The difficulty
+// is the value that is compared to the work threshold of b.
+//
+// May return ErrWorkMissing.
+func (b Block) Difficulty() (uint64, error) {
+	if b.Work == "" {
+		return 0, ErrWorkMissing
 	}
-	nonce, err := findNonce(workThreshold, hash)
+	hash, err := b.workHash()
+	if err != nil {
+		return 0, err
+	}
+	return WorkDifficulty(hash, b.Work)
+}
+
+// ValidateWork ensures that b.Work reaches the threshold required for
+// blocks of b.SubType. Note that SubType is not part of the JSON
+// representation of a Block, so it must be set before validating
+// blocks that have been unmarshalled.
+//
+// May return ErrWorkMissing or an *InsufficientWorkError.
+func (b Block) ValidateWork() error {
+	return b.validateWorkDifficulty(0)
+}
+
+// validateWorkDifficulty is like ValidateWork, but requires that
+// b.Work also reaches minimum.
+func (b Block) validateWorkDifficulty(minimum uint64) error {
+	difficulty, err := b.Difficulty()
 	if err != nil {
 		return err
 	}
-	b.Work = fmt.Sprintf("%016x", nonce)
+	threshold := b.minimumDifficulty(minimum)
+	if difficulty < threshold {
+		return &InsufficientWorkError{Difficulty: difficulty, Threshold: threshold}
+	}
 	return nil
 }
 
+// minimumDifficulty returns difficulty or the threshold of b, whichever
+// is higher.
+func (b Block) minimumDifficulty(difficulty uint64) uint64 {
+	if threshold := b.WorkThreshold(); difficulty < threshold {
+		return threshold
+	}
+	return difficulty
+}
+
+// WorkThreshold returns the base difficulty, that the work of b must
+// reach. It depends on b.SubType.
+func (b Block) WorkThreshold() uint64 {
+	if b.SubType == SubTypeReceive || b.SubType == SubTypeEpoch {
+		// Receive and epoch blocks need less work, so lower the
+		// difficulty.
+		return ReceiveWorkThreshold
+	}
+	return DefaultWorkThreshold
+}
+
+// WorkRoot returns the hash, that the work of b is computed for. It
+// is the hash of the previous block or, for the first block of an
+// account, the account's public key.
+func (b Block) WorkRoot() (string, error) {
+	return b.workHash()
+}
+
 func (b Block) workHash() (string, error) {
 	if b.Previous == strings.Repeat("0", 64) {
 		publicKey, err := getPublicKeyFromAddress(b.Account)
@@ -196,11 +329,10 @@ func (b Block) hashBytes() ([]byte, error) {
 	}
 	copy(msg[96:128], bigIntToBytes(representative, 32))
 
-	balance, ok := big.NewInt(0).SetString(b.Balance, 10)
-	if !ok {
-		return nil, fmt.Errorf("cannot parse '%s' as an integer", b.Balance)
+	if err = b.Balance.validate(); err != nil {
+		return nil, err
 	}
-	copy(msg[128:144], bigIntToBytes(balance, 16))
+	copy(msg[128:144], bigIntToBytes(b.Balance.bigInt(), 16))
 
 	link, err := hex.DecodeString(b.Link)
 	if err != nil {
@@ -215,8 +347,17 @@ func (b Block) hashBytes() ([]byte, error) {
 // Submit submits the Block to the given node. Work and Signature of b
 // must be populated beforehand.
 //
-// May return ErrWorkMissing or ErrSignatureMissing.
+// May return ErrWorkMissing, ErrSignatureMissing or a *ProcessError.
 func (b Block) Submit(node string) error {
+	return b.submit(context.Background(), nodeClient(node))
+}
+
+// SubmitContext is like Submit, but aborts when ctx is done.
+func (b Block) SubmitContext(ctx context.Context, node string) error {
+	return b.submit(ctx, nodeClient(node))
+}
+
+func (b Block) submit(ctx context.Context, c *Client) error {
 	if b.Work == "" {
 		return ErrWorkMissing
 	}
@@ -231,6 +372,8 @@ func (b Block) Submit(node string) error {
 		subType = "change"
 	case SubTypeSend:
 		subType = "send"
+	case SubTypeEpoch:
+		subType = "epoch"
 	}
 	process := process{
 		Action:    "process",
@@ -238,5 +381,5 @@ func (b Block) Submit(node string) error {
 		SubType:   subType,
 		Block:     b,
 	}
-	return doProcessRPC(process, node)
+	return doProcessRPC(ctx, process, c)
 }
@@ -0,0 +1,98 @@
+package atto
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// chainPageSize is the number of blocks fetched per account_history
+// RPC when verifying a chain.
+const chainPageSize = 1000
+
+// ChainError describes the first inconsistency found in the chain of
+// an account. It wraps ErrAccountManipulated, so errors.Is can be used
+// to detect it.
+type ChainError struct {
+	Hash   string
+	Height uint64
+	Reason string
+}
+
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("block %s at height %d: %s", e.Hash, e.Height, e.Reason)
+}
+
+// Unwrap returns ErrAccountManipulated.
+func (e *ChainError) Unwrap() error {
+	return ErrAccountManipulated
+}
+
+// VerifyChain walks the entire chain of Account, from the frontier down
+// to the first block, and verifies each block's hash and signature, the
+// linkage to its predecessor and the change of its balance.
+//
+// May return ErrAccountNotFound or a *ChainError describing the first
+// inconsistency that was found, starting from the frontier.
+func (a Account) VerifyChain(node string) error {
+	return a.verifyChain(context.Background(), nodeClient(node))
+}
+
+// VerifyChainContext is like VerifyChain, but aborts when ctx is done.
+func (a Account) VerifyChainContext(ctx context.Context, node string) error {
+	return a.verifyChain(ctx, nodeClient(node))
+}
+
+// VerifyChain verifies the chain of a using the node. See
+// Account.VerifyChain for details.
+func (c *Client) VerifyChain(a Account) error {
+	return a.verifyChain(context.Background(), c)
+}
+
+// VerifyChainContext is like VerifyChain, but aborts when ctx is done.
+func (c *Client) VerifyChainContext(ctx context.Context, a Account) error {
+	return a.verifyChain(ctx, c)
+}
+
+func (a Account) verifyChain(ctx context.Context, c *Client) error {
+	info, err := a.fetchAccountInfo(ctx, c)
+	if err != nil {
+		return err
+	}
+	options := HistoryOptions{Count: chainPageSize, Head: info.Frontier}
+	var newest *HistoryEntry // The oldest entry of the previous page.
+	for {
+		history, err := a.fetchRawHistory(ctx, c, options)
+		if err != nil {
+			return err
+		}
+		if len(history.History) == 0 {
+			return &ChainError{options.Head, 0, "block is missing from history"}
+		}
+		entries, err := a.toHistoryEntries(history.History)
+		if err != nil {
+			return err
+		}
+		if newest != nil {
+			// Also verify the link between this and the previous page.
+			entries = append([]HistoryEntry{*newest}, entries...)
+		} else if entries[0].Hash != info.Frontier {
+			return &ChainError{entries[0].Hash, entries[0].Height, "history does not start at the frontier"}
+		}
+		if err = verifyHistoryAmounts(entries); err != nil {
+			return err
+		}
+		oldest := entries[len(entries)-1]
+		if history.Previous == "" || oldest.Block.PreviousHash() == strings.Repeat("0", 64) {
+			if oldest.Height != 1 || oldest.Block.PreviousHash() != strings.Repeat("0", 64) {
+				return &ChainError{oldest.Hash, oldest.Height, "chain ends before the first block"}
+			}
+			return nil
+		}
+		if history.Previous != oldest.Block.PreviousHash() {
+			return &ChainError{oldest.Hash, oldest.Height, "previous block does not match"}
+		}
+		newest = &oldest
+		options.Head = history.Previous
+	}
+}
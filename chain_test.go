@@ -0,0 +1,217 @@
+package atto
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeChain is a signed chain of three blocks, newest first. Its first
+// block receives source, a legacy send block of sender.
+type fakeChain struct {
+	account Account
+	blocks  []accountHistoryBlock
+	sender  Account
+	source  LegacyBlock
+
+	// counterparties are the accounts, that the node reports in the
+	// "account" field of the history entries of blocks.
+	counterparties []string
+}
+
+func newFakeChain(t *testing.T) fakeChain {
+	privateKey, err := NewPrivateKey("D420296F5FEF486175FAA8F649DED00A5B0A096DB8D03972937542C51A7F296C", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := NewAccount(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	senderKey, err := NewPrivateKey("D420296F5FEF486175FAA8F649DED00A5B0A096DB8D03972937542C51A7F296C", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender, err := NewAccount(senderKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const genesisHash = "991CF190094C00F0B68E2E5F75F6BEE95A2E0BD93CEAA4A6734DB9F19B728948"
+	source, sourceHash := newLegacySend(t, senderKey, genesisHash, account.Address, 1000)
+	receivable := Receivable{Hash: sourceHash, Amount: mustParseAmount(t, "2", Mnano)}
+	info, open, err := account.FirstReceive(receivable, account.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	change, err := info.Change(sender.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	send, err := info.Send(mustParseAmount(t, "1", Mnano), sender.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := fakeChain{account: account, sender: sender, source: source}
+	// Change blocks have no counterparty.
+	chain.counterparties = []string{sender.Address, "", sender.Address}
+	blocks := []Block{send, change, open}
+	subTypes := []string{"send", "change", "open"}
+	amounts := []Amount{mustParseAmount(t, "1", Mnano), {}, receivable.Amount}
+	for i, block := range blocks {
+		if err = block.Sign(privateKey); err != nil {
+			t.Fatal(err)
+		}
+		hash, err := block.Hash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		chain.blocks = append(chain.blocks, accountHistoryBlock{
+			Block:          block,
+			SubTypeName:    subTypes[i],
+			Hash:           hash,
+			Amount:         amounts[i],
+			Height:         strconv.Itoa(3 - i),
+			LocalTimestamp: "1600000000",
+			Confirmed:      "true",
+		})
+	}
+	return chain
+}
+
+func mustParseAmount(t *testing.T, s string, unit Unit) Amount {
+	amount, err := ParseAmount(s, unit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return amount
+}
+
+func (f fakeChain) serve(w http.ResponseWriter, r *http.Request) {
+	var request map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&request)
+	frontier := f.blocks[0].Block.(Block)
+	switch request["action"] {
+	case "account_info":
+		json.NewEncoder(w).Encode(map[string]string{
+			"frontier":       f.blocks[0].Hash,
+			"representative": frontier.Representative,
+			"balance":        frontier.Balance.String(),
+		})
+	case "block_info":
+		json.NewEncoder(w).Encode(map[string]interface{}{"contents": frontier})
+	case "account_history":
+		// Like a real node, put the counterparty instead of the block's
+		// account into "account".
+		history := make([]map[string]interface{}, len(f.blocks))
+		for i, block := range f.blocks {
+			blockJSON, _ := json.Marshal(block.Block)
+			json.Unmarshal(blockJSON, &history[i])
+			delete(history[i], "account")
+			if f.counterparties[i] != "" {
+				history[i]["account"] = f.counterparties[i]
+			}
+			if legacy, ok := block.Block.(LegacyBlock); ok && legacy.Type == "open" {
+				history[i]["opened"] = legacy.Account
+			}
+			history[i]["subtype"] = block.SubTypeName
+			history[i]["hash"] = block.Hash
+			history[i]["amount"] = block.Amount
+			history[i]["height"] = block.Height
+			history[i]["local_timestamp"] = block.LocalTimestamp
+			history[i]["confirmed"] = block.Confirmed
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"history": history})
+	}
+}
+
+func TestVerifyChain(t *testing.T) {
+	chain := newFakeChain(t)
+	server := httptest.NewServer(http.HandlerFunc(chain.serve))
+	defer server.Close()
+	if err := NewClient(server.URL).VerifyChain(chain.account); err != nil {
+		t.Errorf("expected valid chain, got %v", err)
+	}
+
+	// Claim a bigger amount for the first receive.
+	chain.blocks[2].Amount = mustParseAmount(t, "3", Mnano)
+	err := NewClient(server.URL).VerifyChain(chain.account)
+	var chainError *ChainError
+	if !errors.As(err, &chainError) || chainError.Height != 1 {
+		t.Errorf("expected *ChainError at height 1, got %v", err)
+	}
+	if !errors.Is(err, ErrAccountManipulated) {
+		t.Errorf("expected %v, got %v", ErrAccountManipulated, err)
+	}
+}
+
+func TestVerifyLegacyChain(t *testing.T) {
+	account, err := NewAccountFromAddress("nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := historyNode(t, historyFixture)
+	defer server.Close()
+	if err = NewClient(server.URL).VerifyChain(account); err != nil {
+		t.Errorf("expected valid chain, got %v", err)
+	}
+
+	// The legacy open block belongs to another account.
+	server = historyNode(t, strings.Replace(historyFixture,
+		`"opened": "nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh"`,
+		`"opened": "nano_1o3igdpf8c4msdgwcop71x4o16zzkhe4kyku4axdi8iwh8wh13e4fwgherik"`, 1))
+	defer server.Close()
+	err = NewClient(server.URL).VerifyChain(account)
+	var chainError *ChainError
+	if !errors.As(err, &chainError) || chainError.Height != 1 {
+		t.Errorf("expected *ChainError at height 1, got %v", err)
+	}
+}
+
+func TestFetchHistoryCounterparties(t *testing.T) {
+	chain := newFakeChain(t)
+	sourceAccount := chain.sender.Address
+	sourceMissing := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if !strings.Contains(string(body), "blocks_info") {
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			chain.serve(w, r)
+		} else if sourceMissing {
+			w.Write([]byte(`{"blocks": {}}`))
+		} else {
+			json.NewEncoder(w).Encode(map[string]interface{}{"blocks": map[string]interface{}{
+				chain.blocks[2].Block.(Block).Link: map[string]interface{}{
+					"block_account": sourceAccount,
+					"contents":      chain.source,
+				},
+			}})
+		}
+	}))
+	defer server.Close()
+	client := NewClient(server.URL)
+	history, err := client.FetchHistory(chain.account, HistoryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counterparty := history.Entries[2].Counterparty; counterparty != chain.sender.Address {
+		t.Errorf("expected counterparty %s, got %s", chain.sender.Address, counterparty)
+	}
+
+	// The node names the wrong sender.
+	sourceAccount = "nano_3t6k35gi95xu6tergt6p69ck76ogmitsa8mnijtpxm9fkcm736xtoncuohr3"
+	if _, err = client.FetchHistory(chain.account, HistoryOptions{}); !errors.Is(err, ErrAccountManipulated) {
+		t.Errorf("expected %v, got %v", ErrAccountManipulated, err)
+	}
+
+	// The node omits the source block.
+	sourceMissing = true
+	if _, err = client.FetchHistory(chain.account, HistoryOptions{}); !errors.Is(err, ErrAccountManipulated) {
+		t.Errorf("expected %v, got %v", ErrAccountManipulated, err)
+	}
+}
@@ -0,0 +1,160 @@
+package atto
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Client is used to communicate with a single Nano node. Multiple
+// clients with different configurations may be used at the same time.
+//
+// The zero value is not usable; at least URL must be set.
+type Client struct {
+	// URL is the address of the node's RPC endpoint.
+	URL string
+
+	// HTTPClient is used to send requests to the node. If it is nil,
+	// http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	// RequestInterceptor is a function that is used to modify all HTTP
+	// requests that are sent to the node by this client. If it is nil,
+	// requests are not modified.
+	RequestInterceptor func(request *http.Request) error
+
+	// Timeout limits the duration of each individual request. If it is
+	// zero, no timeout is applied.
+	Timeout time.Duration
+
+	// VerifyReceivables enables the verification of all receivables
+	// returned by FetchReceivable and FetchReceivables. See
+	// Account.VerifyReceivable for details.
+	VerifyReceivables bool
+
+	// BatchSize is the maximum number of accounts or hashes sent in a
+	// single RPC by the batched methods, like FetchBalances. Bigger
+	// requests are split automatically. If it is not positive,
+	// defaultBatchSize is used.
+	BatchSize int
+}
+
+// NewClient creates a new Client, which sends its requests to the
+// node at url.
+func NewClient(url string) *Client {
+	return &Client{URL: url}
+}
+
+// nodeClient returns a Client for node, which behaves like the package
+// level functions always did: it uses http.DefaultClient and the
+// global RequestInterceptor.
+func nodeClient(node string) *Client {
+	return &Client{URL: node, RequestInterceptor: RequestInterceptor}
+}
+
+// FetchAccountInfo fetches the AccountInfo of a from the node. See
+// Account.FetchAccountInfo for details.
+func (c *Client) FetchAccountInfo(a Account) (AccountInfo, error) {
+	return a.fetchAccountInfo(context.Background(), c)
+}
+
+// FetchAccountInfoContext is like FetchAccountInfo, but aborts when
+// ctx is done.
+func (c *Client) FetchAccountInfoContext(ctx context.Context, a Account) (AccountInfo, error) {
+	return a.fetchAccountInfo(ctx, c)
+}
+
+// FetchReceivable fetches all unreceived blocks of a from the node. If
+// c.VerifyReceivables is true, they are verified and
+// ErrAccountManipulated is returned if any of them has been
+// manipulated.
+func (c *Client) FetchReceivable(a Account) ([]Receivable, error) {
+	return a.fetchReceivable(context.Background(), c)
+}
+
+// FetchReceivableContext is like FetchReceivable, but aborts when ctx
+// is done.
+func (c *Client) FetchReceivableContext(ctx context.Context, a Account) ([]Receivable, error) {
+	return a.fetchReceivable(ctx, c)
+}
+
+// VerifyReceivable verifies receivable using the node. See
+// Account.VerifyReceivable for details.
+func (c *Client) VerifyReceivable(a Account, receivable Receivable) error {
+	return a.verifyReceivables(context.Background(), c, []Receivable{receivable})
+}
+
+// VerifyReceivableContext is like VerifyReceivable, but aborts when
+// ctx is done.
+func (c *Client) VerifyReceivableContext(ctx context.Context, a Account, receivable Receivable) error {
+	return a.verifyReceivables(ctx, c, []Receivable{receivable})
+}
+
+// FetchWork uses the generate_work RPC on the node to fetch and then
+// set the Work of b.
+func (c *Client) FetchWork(b *Block) error {
+	return b.fetchWork(context.Background(), c, 0)
+}
+
+// FetchWorkContext is like FetchWork, but aborts when ctx is done.
+func (c *Client) FetchWorkContext(ctx context.Context, b *Block) error {
+	return b.fetchWork(ctx, c, 0)
+}
+
+// FetchWorkDifficulty is like FetchWork, but the work must reach
+// difficulty, if it is higher than the threshold of b.
+func (c *Client) FetchWorkDifficulty(b *Block, difficulty uint64) error {
+	return b.fetchWork(context.Background(), c, difficulty)
+}
+
+// FetchWorkDifficultyContext is like FetchWorkDifficulty, but aborts
+// when ctx is done.
+func (c *Client) FetchWorkDifficultyContext(ctx context.Context, b *Block, difficulty uint64) error {
+	return b.fetchWork(ctx, c, difficulty)
+}
+
+// Submit submits b to the node. See Block.Submit for details.
+func (c *Client) Submit(b Block) error {
+	return b.submit(context.Background(), c)
+}
+
+// SubmitContext is like Submit, but aborts when ctx is done.
+func (c *Client) SubmitContext(ctx context.Context, b Block) error {
+	return b.submit(ctx, c)
+}
+
+func (c *Client) doRPC(ctx context.Context, requestBody string) (responseBytes []byte, err error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", c.URL, strings.NewReader(requestBody))
+	if err != nil {
+		return
+	}
+	req.Header.Add("Content-Type", "application/json")
+	if c.RequestInterceptor != nil {
+		if err = c.RequestInterceptor(req); err != nil {
+			err = fmt.Errorf("request interceptor failed: %v", err)
+			return
+		}
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		err = fmt.Errorf("received unexpected HTTP return code %d", resp.StatusCode)
+		return
+	}
+	return ioutil.ReadAll(resp.Body)
+}
@@ -0,0 +1,98 @@
+package atto
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func testClientAccount(t *testing.T) Account {
+	account, err := NewAccountFromAddress("nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return account
+}
+
+func TestClientTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+	client := NewClient(server.URL)
+	client.Timeout = 20 * time.Millisecond
+	// Without the timeout, the request would block until the test ends.
+	_, err := client.FetchAccountInfo(testClientAccount(t))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+// recordingTransport counts the requests it forwards.
+type recordingTransport struct {
+	requests int
+}
+
+func (t *recordingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.requests++
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestClientHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"error": "Account not found"}`))
+	}))
+	defer server.Close()
+	transport := &recordingTransport{}
+	client := NewClient(server.URL)
+	client.HTTPClient = &http.Client{Transport: transport}
+	if _, err := client.FetchAccountInfo(testClientAccount(t)); err != ErrAccountNotFound {
+		t.Errorf("expected %v, got %v", ErrAccountNotFound, err)
+	}
+	if transport.requests != 1 {
+		t.Errorf("expected 1 request through the custom HTTP client, got %d", transport.requests)
+	}
+}
+
+func TestClientRequestInterceptor(t *testing.T) {
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.Write([]byte(`{"error": "Account not found"}`))
+	}))
+	defer server.Close()
+	global := RequestInterceptor
+	defer func() { RequestInterceptor = global }()
+	RequestInterceptor = func(r *http.Request) error {
+		r.Header.Set("X-Interceptor", "global")
+		return nil
+	}
+	intercepted := NewClient(server.URL)
+	intercepted.RequestInterceptor = func(r *http.Request) error {
+		r.Header.Set("X-Interceptor", "client")
+		return nil
+	}
+	account := testClientAccount(t)
+	tests := []struct {
+		name        string
+		fetch       func() (AccountInfo, error)
+		interceptor string
+	}{
+		{"own interceptor", func() (AccountInfo, error) { return intercepted.FetchAccountInfo(account) }, "client"},
+		{"other client", func() (AccountInfo, error) { return NewClient(server.URL).FetchAccountInfo(account) }, ""},
+		{"package function", func() (AccountInfo, error) { return account.FetchAccountInfo(server.URL) }, "global"},
+	}
+	for _, test := range tests {
+		header = nil
+		if _, err := test.fetch(); err != ErrAccountNotFound {
+			t.Errorf("%s: expected %v, got %v", test.name, ErrAccountNotFound, err)
+		} else if interceptor := header.Get("X-Interceptor"); interceptor != test.interceptor {
+			t.Errorf("%s: expected interceptor '%s', got '%s'", test.name, test.interceptor, interceptor)
+		}
+	}
+}
@@ -6,7 +6,7 @@ import (
 	"flag"
 	"fmt"
 	"io"
-	"math/big"
+	"math"
 	"net/http"
 	"os"
 
@@ -57,17 +57,12 @@ Environment:
 	                          Authentication.
 `
 
-type workSourceType int
-
-const (
-	workSourceLocal workSourceType = iota
-	workSourceNode
-	workSourceLocalFallback
-)
-
 var accountIndexFlag uint
 var yFlag bool
 
+// client is used for all communication with node.
+var client = atto.NewClient(node)
+
 func init() {
 	var vFlag bool
 	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
@@ -79,7 +74,7 @@ func init() {
 		fmt.Println("1.4.0")
 		os.Exit(0)
 	}
-	if accountIndexFlag >= 1<<32 || flag.NArg() < 2 {
+	if uint64(accountIndexFlag) > math.MaxUint32 || flag.NArg() < 2 {
 		flag.Usage()
 		os.Exit(1)
 	}
@@ -96,14 +91,16 @@ func init() {
 		flag.Usage()
 		os.Exit(1)
 	}
-	setUpNodeAuthentication()
+	setUpClient()
 }
 
-func setUpNodeAuthentication() {
+func setUpClient() {
+	// Don't trust the node to report receivable amounts correctly.
+	client.VerifyReceivables = true
 	if os.Getenv("ATTO_BASIC_AUTH_USERNAME") != "" {
 		username := os.Getenv("ATTO_BASIC_AUTH_USERNAME")
 		password := os.Getenv("ATTO_BASIC_AUTH_PASSWORD")
-		atto.RequestInterceptor = func(request *http.Request) error {
+		client.RequestInterceptor = func(request *http.Request) error {
 			request.SetBasicAuth(username, password)
 			return nil
 		}
@@ -146,11 +143,14 @@ func receive() error {
 	} else if err != nil {
 		return err
 	}
-	receivables, err := account.FetchReceivable(node)
+	receivables, err := client.FetchReceivable(account)
 	if err != nil {
 		return err
 	}
-	for _, receivable := range receivables {
+	// All blocks are created first, so that their work can be obtained
+	// concurrently.
+	blocks := make([]*atto.Block, len(receivables))
+	for i, receivable := range receivables {
 		var block atto.Block
 		if firstReceive {
 			info, block, err = account.FirstReceive(receivable, defaultRepresentative)
@@ -161,15 +161,17 @@ func receive() error {
 		if err != nil {
 			return err
 		}
-		if err = fillWork(&block, node); err != nil {
-			return err
-		}
+		blocks[i] = &block
+	}
+	if err = fillWorks(blocks); err != nil {
+		return err
+	}
+	for _, block := range blocks {
 		blockJSON, err := json.Marshal(block)
 		if err != nil {
 			return err
 		}
-		err = appendLineToFile(blockJSON)
-		if err != nil {
+		if err = appendLineToFile(blockJSON); err != nil {
 			return err
 		}
 	}
@@ -194,7 +196,7 @@ func change() error {
 	if err != nil {
 		return err
 	}
-	if err = fillWork(&block, node); err != nil {
+	if err = fillWork(&block); err != nil {
 		return err
 	}
 	blockJSON, err := json.Marshal(block)
@@ -205,7 +207,10 @@ func change() error {
 }
 
 func send() error {
-	amount := flag.Arg(2)
+	amount, err := atto.ParseAmount(flag.Arg(2), atto.Mnano)
+	if err != nil {
+		return err
+	}
 	receiver := flag.Arg(3)
 	addr, err := getFirstStdinLine()
 	if err != nil {
@@ -223,7 +228,7 @@ func send() error {
 	if err != nil {
 		return err
 	}
-	if err = fillWork(&block, node); err != nil {
+	if err = fillWork(&block); err != nil {
 		return err
 	}
 	blockJSON, err := json.Marshal(block)
@@ -294,26 +299,16 @@ func submit() error {
 		return err
 	}
 
-	var oldBalance *big.Int
-	info, err := account.FetchAccountInfo(node)
-	if err == atto.ErrAccountNotFound {
-		oldBalance = big.NewInt(0)
-	} else if err != nil {
+	var oldBalance atto.Amount
+	info, err := client.FetchAccountInfo(account)
+	if err == nil {
+		oldBalance = info.Balance
+	} else if err != atto.ErrAccountNotFound {
 		return err
-	} else {
-		var ok bool
-		oldBalance, ok = big.NewInt(0).SetString(info.Balance, 10)
-		if !ok {
-			return fmt.Errorf("cannot parse '%s' as an integer", info.Balance)
-		}
 	}
 
 	for _, block := range blocks {
-		newBalance, ok := big.NewInt(0).SetString(block.Balance, 10)
-		if !ok {
-			return fmt.Errorf("cannot parse '%s' as an integer", block.Balance)
-		}
-		switch oldBalance.Cmp(newBalance) {
+		switch oldBalance.Cmp(block.Balance) {
 		case -1:
 			block.SubType = atto.SubTypeReceive
 		case 0:
@@ -323,12 +318,12 @@ func submit() error {
 			block.SubType = atto.SubTypeSend
 		}
 		fmt.Fprint(os.Stderr, "Submitting block... ")
-		err = block.Submit(node)
+		err = client.Submit(block)
 		if err != nil {
 			return err
 		}
 		fmt.Fprintln(os.Stderr, "done")
-		oldBalance = newBalance
+		oldBalance = block.Balance
 	}
 	return nil
 }
@@ -2,11 +2,11 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
-	"math/big"
 	"os"
 	"runtime"
 	"strings"
@@ -33,7 +33,7 @@ func getLatestAccountInfo(acc atto.Account) (atto.AccountInfo, error) {
 		return atto.AccountInfo{}, err
 	}
 	if len(blocks) == 0 {
-		return acc.FetchAccountInfo(node)
+		return client.FetchAccountInfo(acc)
 	}
 	latestBlock := blocks[len(blocks)-1]
 	hash, err := latestBlock.Hash()
@@ -88,29 +88,10 @@ func appendLineToFile(in []byte) error {
 	return err
 }
 
-func rawToNanoString(raw *big.Int) string {
-	rawPerNano, _ := big.NewInt(0).SetString("1000000000000000000000000000000", 10)
-	absRaw := big.NewInt(0).Abs(raw)
-	integerDigits, fractionalDigits := big.NewInt(0).QuoRem(absRaw, rawPerNano, big.NewInt(0))
-	res := integerDigits.String()
-	if fractionalDigits.Sign() != 0 {
-		fractionalDigitsString := fmt.Sprintf("%030s", fractionalDigits.String())
-		res += "." + strings.TrimRight(fractionalDigitsString, "0")
-	}
-	if raw.Sign() < 0 {
-		return "-" + res + " NANO"
-	}
-	return res + " NANO"
-}
-
 func letUserVerifyBlock(block atto.Block) (err error) {
 	if !yFlag {
-		balanceInt, ok := big.NewInt(0).SetString(block.Balance, 10)
-		if !ok {
-			return fmt.Errorf("cannot parse '%s' as an integer", block.Balance)
-		}
-		balanceNano := rawToNanoString(balanceInt)
-		txt := "Sign block that sets balance to %s and representative to %s? [y/N]: "
+		balanceNano := block.Balance.Text(atto.Mnano)
+		txt := "Sign block that sets balance to %s NANO and representative to %s? [y/N]: "
 		fmt.Fprintf(os.Stderr, txt, balanceNano, block.Representative)
 
 		// Explicitly openning /dev/tty or CONIN$ ensures function, even if
@@ -137,18 +118,28 @@ func letUserVerifyBlock(block atto.Block) (err error) {
 	return
 }
 
-func fillWork(block *atto.Block, node string) error {
-	switch workSource {
-	case workSourceLocal:
-		return block.GenerateWork()
-	case workSourceNode:
-		return block.FetchWork(node)
-	case workSourceLocalFallback:
-		if err := block.FetchWork(node); err != nil {
-			fmt.Fprintf(os.Stderr, "Could not fetch work from node (error: %v); generating it on CPU...\n", err)
-			return block.GenerateWork()
-		}
-		return nil
+func fillWork(block *atto.Block) error {
+	return workProvider().ProvideWork(context.Background(), block)
+}
+
+// fillWorks fills the work of all blocks, obtaining the work of up to
+// workConcurrency blocks at once.
+func fillWorks(blocks []*atto.Block) error {
+	if len(blocks) > 1 {
+		fmt.Fprintf(os.Stderr, "Generating work for %d blocks... ", len(blocks))
+	}
+	err := atto.ProvideWorkConcurrently(context.Background(), workProvider(), blocks, workConcurrency)
+	if err == nil && len(blocks) > 1 {
+		fmt.Fprintln(os.Stderr, "done")
+	}
+	return err
+}
+
+func workProvider() atto.WorkProvider {
+	return atto.FallbackWorkProvider{
+		Providers: workProviders,
+		OnFailure: func(_ atto.WorkProvider, err error) {
+			fmt.Fprintf(os.Stderr, "Could not get work (error: %v); trying next work provider...\n", err)
+		},
 	}
-	return fmt.Errorf("unknown work source")
 }
@@ -0,0 +1,24 @@
+package main
+
+import "github.com/codesoap/atto"
+
+var (
+	// listenAddress is the address on which the RPC actions are served,
+	// unless the -l flag is given. 7076 is the default RPC port of the
+	// nano node.
+	listenAddress = "localhost:7076"
+
+	// workOptions control the CPU usage of the work generation. Set
+	// them e.g. to atto.WorkOptions{Workers: 2, Throttle: 0.5} to leave
+	// CPU time for other processes.
+	workOptions = atto.WorkOptions{}
+
+	// concurrentJobs is the number of hashes, whose work is generated
+	// at the same time. Each job already uses all workers of
+	// workOptions, so additional jobs only share the CPU.
+	concurrentJobs = 1
+
+	// maxMultiplier limits the difficulty clients may request, relative
+	// to atto.DefaultWorkThreshold.
+	maxMultiplier = 64.0
+)
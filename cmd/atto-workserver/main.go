@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/codesoap/atto"
+)
+
+var usage = `Usage:
+	atto-workserver -v
+	atto-workserver [-l LISTEN_ADDRESS]
+
+If the -v flag is provided, atto-workserver will print its version
+number.
+
+atto-workserver serves the work_generate, work_validate and work_cancel
+RPC actions of the nano node via HTTP at LISTEN_ADDRESS, which defaults
+to localhost:7076. The work is generated on the CPU of the local
+computer. Requests for the same hash are answered with the same work,
+if they arrive while it is still being generated. If all clients
+waiting for a hash disconnect, its work generation is cancelled.
+`
+
+var lFlag string
+
+// maxRequestSize limits the size of request bodies in bytes.
+const maxRequestSize = 1 << 16
+
+var queue *jobQueue
+
+// parseFlags is not run in init, so that the tests of this package can
+// use their own flags.
+func parseFlags() {
+	var vFlag bool
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.StringVar(&lFlag, "l", listenAddress, "")
+	flag.BoolVar(&vFlag, "v", false, "")
+	flag.Parse()
+	if vFlag {
+		fmt.Println("1.6.0")
+		os.Exit(0)
+	}
+	if flag.NArg() != 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+}
+
+func main() {
+	parseFlags()
+	queue = newJobQueue(concurrentJobs, func(ctx context.Context, hash string, difficulty uint64) (string, error) {
+		return atto.GenerateWorkForRoot(ctx, hash, difficulty, workOptions)
+	})
+	http.HandleFunc("/", handleRPC)
+	log.Printf("Listening on %s.", lFlag)
+	if err := http.ListenAndServe(lFlag, nil); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+}
+
+type rpcRequest struct {
+	Action     string `json:"action"`
+	Hash       string `json:"hash"`
+	Work       string `json:"work"`
+	Difficulty string `json:"difficulty"`
+	Multiplier string `json:"multiplier"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type workGenerateResponse struct {
+	Work       string `json:"work"`
+	Difficulty string `json:"difficulty"`
+	Multiplier string `json:"multiplier"`
+	Hash       string `json:"hash"`
+}
+
+type workValidateResponse struct {
+	Valid        string `json:"valid,omitempty"`
+	ValidAll     string `json:"valid_all"`
+	ValidReceive string `json:"valid_receive"`
+	Difficulty   string `json:"difficulty"`
+	Multiplier   string `json:"multiplier"`
+}
+
+type workCancelResponse struct {
+	Success string `json:"success"`
+}
+
+// handleRPC answers requests like the nano node does: errors are
+// reported in the "error" field of a response with status code 200.
+func handleRPC(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST requests are supported", http.StatusMethodNotAllowed)
+		return
+	}
+	var request rpcRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err := decoder.Decode(&request); err != nil {
+		writeResponse(w, errorResponse{"Unable to parse JSON"})
+		return
+	}
+	var response interface{}
+	var err error
+	switch request.Action {
+	case "work_generate":
+		response, err = workGenerate(r, request)
+	case "work_validate":
+		response, err = workValidate(request)
+	case "work_cancel":
+		response, err = workCancel(request)
+	default:
+		err = fmt.Errorf("Unknown command")
+	}
+	if err != nil {
+		if r.Context().Err() != nil {
+			return // The client is gone.
+		}
+		log.Printf("%s failed: %v", request.Action, err)
+		response = errorResponse{err.Error()}
+	}
+	writeResponse(w, response)
+}
+
+func writeResponse(w http.ResponseWriter, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Could not write response: %v", err)
+	}
+}
+
+func workGenerate(r *http.Request, request rpcRequest) (interface{}, error) {
+	hash, err := parseHash(request.Hash)
+	if err != nil {
+		return nil, err
+	}
+	difficulty, err := parseDifficulty(request, atto.DefaultWorkThreshold)
+	if err != nil {
+		return nil, err
+	}
+	work, err := queue.generate(r.Context(), hash, difficulty)
+	if err != nil {
+		return nil, err
+	}
+	// The job may have been raised to a higher difficulty by another
+	// request.
+	value, err := atto.WorkDifficulty(hash, work)
+	if err != nil {
+		return nil, err
+	}
+	return workGenerateResponse{
+		Work:       work,
+		Difficulty: fmt.Sprintf("%016x", value),
+		Multiplier: formatMultiplier(value),
+		Hash:       hash,
+	}, nil
+}
+
+func workValidate(request rpcRequest) (interface{}, error) {
+	hash, err := parseHash(request.Hash)
+	if err != nil {
+		return nil, err
+	}
+	value, err := atto.WorkDifficulty(hash, request.Work)
+	if err != nil {
+		return nil, fmt.Errorf("Bad work")
+	}
+	response := workValidateResponse{
+		ValidAll:     formatBool(value >= atto.DefaultWorkThreshold),
+		ValidReceive: formatBool(value >= atto.ReceiveWorkThreshold),
+		Difficulty:   fmt.Sprintf("%016x", value),
+		Multiplier:   formatMultiplier(value),
+	}
+	if request.Difficulty != "" || request.Multiplier != "" {
+		difficulty, err := parseDifficulty(request, 0)
+		if err != nil {
+			return nil, err
+		}
+		response.Valid = formatBool(value >= difficulty)
+	}
+	return response, nil
+}
+
+func workCancel(request rpcRequest) (interface{}, error) {
+	hash, err := parseHash(request.Hash)
+	if err != nil {
+		return nil, err
+	}
+	queue.cancel(hash)
+	return workCancelResponse{}, nil
+}
+
+// parseHash validates hash and returns it in upper case, so that
+// requests for the same hash share a job regardless of the case.
+func parseHash(hash string) (string, error) {
+	if bytes, err := hex.DecodeString(hash); err != nil || len(bytes) != 32 {
+		return "", fmt.Errorf("Bad block hash number")
+	}
+	return strings.ToUpper(hash), nil
+}
+
+// parseDifficulty returns the difficulty or multiplier of request, or
+// fallback if neither is given. The difficulty must be within the
+// range of valid thresholds and maxMultiplier.
+func parseDifficulty(request rpcRequest, fallback uint64) (uint64, error) {
+	difficulty := fallback
+	if request.Difficulty != "" {
+		var err error
+		if difficulty, err = strconv.ParseUint(request.Difficulty, 16, 64); err != nil {
+			return 0, fmt.Errorf("Bad difficulty")
+		}
+	} else if request.Multiplier != "" {
+		multiplier, err := strconv.ParseFloat(request.Multiplier, 64)
+		if err != nil || multiplier <= 0 {
+			return 0, fmt.Errorf("Bad multiplier")
+		}
+		difficulty = atto.DifficultyFromMultiplier(atto.DefaultWorkThreshold, multiplier)
+	} else {
+		return difficulty, nil
+	}
+	max := atto.DifficultyFromMultiplier(atto.DefaultWorkThreshold, maxMultiplier)
+	if difficulty < atto.ReceiveWorkThreshold || difficulty > max {
+		return 0, fmt.Errorf("Difficulty out of valid range")
+	}
+	return difficulty, nil
+}
+
+func formatMultiplier(difficulty uint64) string {
+	multiplier := atto.MultiplierFromDifficulty(atto.DefaultWorkThreshold, difficulty)
+	return strconv.FormatFloat(multiplier, 'f', -1, 64)
+}
+
+func formatBool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
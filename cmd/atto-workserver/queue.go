@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/codesoap/atto"
+)
+
+// generateFunc searches for work for hash, that reaches difficulty,
+// until ctx is done.
+type generateFunc func(ctx context.Context, hash string, difficulty uint64) (string, error)
+
+// errCancelled is returned to all clients waiting for a job, that was
+// cancelled with work_cancel. The message matches the nano node.
+var errCancelled = fmt.Errorf("Cancelled")
+
+// job is the generation of work for a single hash. Concurrent requests
+// for the same hash share one job.
+type job struct {
+	hash       string
+	difficulty uint64
+	waiters    int
+	cancelled  bool
+	finished   bool
+
+	// cancel stops the running search; it is nil while the job is
+	// queued.
+	cancel context.CancelFunc
+
+	done chan struct{}
+	work string
+	err  error
+}
+
+// jobQueue schedules jobs in the order they were requested.
+type jobQueue struct {
+	generateWork generateFunc
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	jobs    map[string]*job
+	pending []*job
+}
+
+// newJobQueue starts workers goroutines, which run the queued jobs
+// using generateWork.
+func newJobQueue(workers int, generateWork generateFunc) *jobQueue {
+	q := &jobQueue{generateWork: generateWork, jobs: make(map[string]*job)}
+	q.cond = sync.NewCond(&q.mu)
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+	return q
+}
+
+// generate returns work for hash, that reaches difficulty. If a job for
+// hash is already queued or running, its result is shared; its
+// difficulty is raised, if necessary. If ctx is done before the work
+// has been found, ctx.Err() is returned and the job is cancelled, if
+// no other client is waiting for it anymore.
+func (q *jobQueue) generate(ctx context.Context, hash string, difficulty uint64) (string, error) {
+	q.mu.Lock()
+	j, ok := q.jobs[hash]
+	if !ok {
+		j = &job{hash: hash, difficulty: difficulty, done: make(chan struct{})}
+		q.jobs[hash] = j
+		q.pending = append(q.pending, j)
+		q.cond.Signal()
+	} else if difficulty > j.difficulty {
+		j.difficulty = difficulty
+		if j.cancel != nil {
+			j.cancel() // The worker restarts with the new difficulty.
+		}
+	}
+	j.waiters++
+	q.mu.Unlock()
+
+	select {
+	case <-j.done:
+		return j.work, j.err
+	case <-ctx.Done():
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if j.finished {
+			// The job finished at the same time; there is nothing to
+			// cancel anymore.
+			return j.work, j.err
+		}
+		j.waiters--
+		if j.waiters == 0 {
+			q.cancelJob(j)
+		}
+		return "", ctx.Err()
+	}
+}
+
+// cancel cancels the job for hash, if there is one.
+func (q *jobQueue) cancel(hash string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if j, ok := q.jobs[hash]; ok {
+		q.cancelJob(j)
+	}
+}
+
+// cancelJob must only be called while q.mu is locked. The job is
+// removed from q.jobs immediately, so that new requests for its hash
+// start a new job, instead of receiving errCancelled.
+func (q *jobQueue) cancelJob(j *job) {
+	if j.finished || j.cancelled {
+		return
+	}
+	j.cancelled = true
+	q.forget(j)
+	if j.cancel != nil {
+		j.cancel() // The worker finishes the job.
+		return
+	}
+	for i, pending := range q.pending {
+		if pending == j {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			break
+		}
+	}
+	q.finish(j, "", errCancelled)
+}
+
+// finish must only be called while q.mu is locked. Finishing a job a
+// second time has no effect.
+func (q *jobQueue) finish(j *job, work string, err error) {
+	if j.finished {
+		return
+	}
+	j.finished = true
+	j.work, j.err = work, err
+	close(j.done)
+	q.forget(j)
+}
+
+// forget removes j from q.jobs, unless another job has already taken
+// its place. It must only be called while q.mu is locked.
+func (q *jobQueue) forget(j *job) {
+	if q.jobs[j.hash] == j {
+		delete(q.jobs, j.hash)
+	}
+}
+
+// work runs queued jobs until the program exits.
+func (q *jobQueue) work() {
+	for {
+		q.mu.Lock()
+		for len(q.pending) == 0 {
+			q.cond.Wait()
+		}
+		j := q.pending[0]
+		q.pending = q.pending[1:]
+		q.run(j)
+		q.mu.Unlock()
+	}
+}
+
+// run generates the work of j. It must be called while q.mu is locked,
+// but unlocks it during the search.
+func (q *jobQueue) run(j *job) {
+	for {
+		difficulty := j.difficulty
+		var ctx context.Context
+		ctx, j.cancel = context.WithCancel(context.Background())
+		q.mu.Unlock()
+		work, err := q.generateWork(ctx, j.hash, difficulty)
+		q.mu.Lock()
+		j.cancel()
+		j.cancel = nil
+		switch {
+		case j.cancelled:
+			q.finish(j, "", errCancelled)
+			return
+		case j.difficulty > difficulty && !reaches(j.hash, work, j.difficulty):
+			continue // The difficulty was raised during the search.
+		}
+		q.finish(j, work, err)
+		return
+	}
+}
+
+// reaches returns true, if work is valid work of difficulty for hash.
+func reaches(hash, work string, difficulty uint64) bool {
+	if work == "" {
+		return false
+	}
+	value, err := atto.WorkDifficulty(hash, work)
+	return err == nil && value >= difficulty
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testHash = "991CF190094C00F0B68E2E5F75F6BEE95A2E0BD93CEAA4A6734DB9F19B728948"
+
+// This work reaches ffffffff25bc7fc3 for testHash.
+const testWork = "0000000006ebdff8"
+
+// fakeGenerator blocks each search until release is called or the
+// search is cancelled.
+type fakeGenerator struct {
+	mu           sync.Mutex
+	difficulties []uint64
+	started      chan struct{}
+	release      chan string
+}
+
+func newFakeGenerator() *fakeGenerator {
+	return &fakeGenerator{started: make(chan struct{}, 10), release: make(chan string)}
+}
+
+func (g *fakeGenerator) generate(ctx context.Context, hash string, difficulty uint64) (string, error) {
+	g.mu.Lock()
+	g.difficulties = append(g.difficulties, difficulty)
+	g.mu.Unlock()
+	g.started <- struct{}{}
+	select {
+	case work := <-g.release:
+		return work, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (g *fakeGenerator) searches() []uint64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]uint64{}, g.difficulties...)
+}
+
+type generateResult struct {
+	work string
+	err  error
+}
+
+func generateAsync(ctx context.Context, q *jobQueue, difficulty uint64) <-chan generateResult {
+	results := make(chan generateResult, 1)
+	go func() {
+		work, err := q.generate(ctx, testHash, difficulty)
+		results <- generateResult{work, err}
+	}()
+	return results
+}
+
+// waitForWaiters waits until the job for testHash has n waiters.
+func waitForWaiters(t *testing.T, q *jobQueue, n int) {
+	for i := 0; i < 1000; i++ {
+		q.mu.Lock()
+		j, ok := q.jobs[testHash]
+		waiters := 0
+		if ok {
+			waiters = j.waiters
+		}
+		q.mu.Unlock()
+		if waiters == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("job never had %d waiters", n)
+}
+
+func TestJobQueueDeduplication(t *testing.T) {
+	g := newFakeGenerator()
+	q := newJobQueue(1, g.generate)
+	first := generateAsync(context.Background(), q, 1)
+	second := generateAsync(context.Background(), q, 1)
+	<-g.started
+	waitForWaiters(t, q, 2)
+	g.release <- testWork
+	for _, results := range []<-chan generateResult{first, second} {
+		if result := <-results; result.err != nil || result.work != testWork {
+			t.Errorf("unexpected result %+v", result)
+		}
+	}
+	if searches := g.searches(); len(searches) != 1 {
+		t.Errorf("expected one search, got %d", len(searches))
+	}
+}
+
+func TestJobQueueRaiseDifficulty(t *testing.T) {
+	g := newFakeGenerator()
+	q := newJobQueue(1, g.generate)
+	low := generateAsync(context.Background(), q, 1)
+	<-g.started
+	high := generateAsync(context.Background(), q, 0xffffffff00000000)
+	<-g.started // The search restarts with the higher difficulty.
+	g.release <- testWork
+	for _, results := range []<-chan generateResult{low, high} {
+		if result := <-results; result.err != nil || result.work != testWork {
+			t.Errorf("unexpected result %+v", result)
+		}
+	}
+	searches := g.searches()
+	if fmt.Sprint(searches) != fmt.Sprint([]uint64{1, 0xffffffff00000000}) {
+		t.Errorf("unexpected searches %x", searches)
+	}
+}
+
+func TestJobQueueCancel(t *testing.T) {
+	g := newFakeGenerator()
+	q := newJobQueue(1, g.generate)
+	cancelled := generateAsync(context.Background(), q, 1)
+	<-g.started
+	waitForWaiters(t, q, 1)
+	q.cancel(testHash)
+
+	// A new request must not receive the cancellation.
+	fresh := generateAsync(context.Background(), q, 1)
+	if result := <-cancelled; result.err != errCancelled {
+		t.Errorf("expected %v, got %v", errCancelled, result.err)
+	}
+	<-g.started
+	g.release <- testWork
+	if result := <-fresh; result.err != nil || result.work != testWork {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestJobQueueDisconnect(t *testing.T) {
+	g := newFakeGenerator()
+	q := newJobQueue(1, g.generate)
+	ctx, cancel := context.WithCancel(context.Background())
+	disconnected := generateAsync(ctx, q, 1)
+	<-g.started
+	waitForWaiters(t, q, 1)
+	cancel()
+	if result := <-disconnected; result.err != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, result.err)
+	}
+	q.mu.Lock()
+	_, ok := q.jobs[testHash]
+	q.mu.Unlock()
+	if ok {
+		t.Error("job was not cancelled after its last client disconnected")
+	}
+}
+
+func TestJobQueueDisconnectOnCompletion(t *testing.T) {
+	q := newJobQueue(1, func(ctx context.Context, hash string, difficulty uint64) (string, error) {
+		return testWork, nil
+	})
+	for i := 0; i < 1000; i++ {
+		// With a done context, generate may notice the disconnect and
+		// the completion of the job at the same time.
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		q.generate(ctx, testHash, 1)
+	}
+
+	// Cancelling a finished job must have no effect.
+	q.mu.Lock()
+	j := &job{hash: testHash, done: make(chan struct{})}
+	q.finish(j, testWork, nil)
+	q.cancelJob(j)
+	q.finish(j, "", errCancelled)
+	q.mu.Unlock()
+	if j.err != nil {
+		t.Errorf("result of finished job was overwritten with %v", j.err)
+	}
+
+	work, err := q.generate(context.Background(), testHash, 1)
+	if err != nil || work != testWork {
+		t.Errorf("queue is stuck: %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"text/tabwriter"
+
+	"github.com/codesoap/atto"
+)
+
+var fromFlag uint
+var toFlag uint
+
+// parseAccountsFlags parses the flags following the accounts
+// subcommand and reports whether they are valid.
+func parseAccountsFlags() bool {
+	flags := flag.NewFlagSet("accounts", flag.ContinueOnError)
+	flags.Usage = func() {}
+	flags.UintVar(&fromFlag, "from", 0, "")
+	flags.UintVar(&toFlag, "to", 9, "")
+	if err := flags.Parse(flag.Args()[1:]); err != nil {
+		return false
+	}
+	return flags.NArg() == 0 && fromFlag <= toFlag && uint64(toFlag) <= math.MaxUint32
+}
+
+func printAccounts() error {
+	seed, err := getSeed()
+	if err != nil {
+		return err
+	}
+	var accounts []atto.Account
+	for index := uint64(fromFlag); index <= uint64(toFlag); index++ {
+		privateKey, err := atto.NewPrivateKey(seed, uint32(index))
+		if err != nil {
+			return err
+		}
+		account, err := atto.NewAccount(privateKey)
+		if err != nil {
+			return err
+		}
+		accounts = append(accounts, account)
+	}
+	balances, err := client.FetchBalances(accounts)
+	if err != nil {
+		return err
+	}
+	representatives, err := client.FetchRepresentatives(accounts)
+	if errors.Is(err, atto.ErrAccountNotFound) {
+		// Older nodes reject the request, if any account is unopened.
+		representatives = make([]string, len(accounts))
+		for i := range representatives {
+			representatives[i] = "?"
+		}
+	} else if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "INDEX\tADDRESS\tBALANCE\tRECEIVABLE\tREPRESENTATIVE")
+	for i, account := range accounts {
+		representative := representatives[i]
+		if representative == "" {
+			representative = "-"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", fromFlag+uint(i), account.Address,
+			balances[i].Balance.Text(atto.Mnano), balances[i].Receivable.Text(atto.Mnano),
+			representative)
+	}
+	return w.Flush()
+}
+
+var gapFlag uint
+
+// parseDiscoverFlags parses the flags following the discover
+// subcommand and reports whether they are valid.
+func parseDiscoverFlags() bool {
+	flags := flag.NewFlagSet("discover", flag.ContinueOnError)
+	flags.Usage = func() {}
+	flags.UintVar(&gapFlag, "gap", 20, "")
+	if err := flags.Parse(flag.Args()[1:]); err != nil {
+		return false
+	}
+	return flags.NArg() == 0 && gapFlag > 0 && uint64(gapFlag) <= math.MaxUint32
+}
+
+func discoverAccounts() error {
+	seed, err := getSeed()
+	if err != nil {
+		return err
+	}
+	options := atto.DiscoveryOptions{Gap: uint32(gapFlag)}
+	discovered, err := client.DiscoverAccounts(seed, options)
+	if err != nil {
+		return err
+	}
+	if len(discovered) == 0 {
+		fmt.Fprintln(os.Stderr, "No used accounts found.")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "INDEX\tADDRESS\tSTATE")
+	for _, account := range discovered {
+		state := "opened"
+		if account.Frontier == "" {
+			state = "receivable"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", account.Index, account.Account.Address, state)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/codesoap/atto"
+)
+
+// batchSend is a single row of a batch-send file.
+type batchSend struct {
+	recipient string
+	amount    atto.Amount
+}
+
+// batchReportSuffix is appended to the name of a batch-send file to
+// get the name of its report.
+const batchReportSuffix = ".report"
+
+func sendBatch() error {
+	sends, err := readBatchFile(flag.Arg(1))
+	if err != nil {
+		return err
+	}
+	reportPath := flag.Arg(1) + batchReportSuffix
+	hashes, err := readBatchReport(reportPath, sends)
+	if err != nil {
+		return err
+	}
+	seed, err := getSeed()
+	if err != nil {
+		return err
+	}
+	privateKey, err := atto.NewPrivateKey(seed, uint32(accountIndexFlag))
+	if err != nil {
+		return err
+	}
+	account, err := atto.NewAccount(privateKey)
+	if err != nil {
+		return err
+	}
+	info, err := client.FetchAccountInfo(account)
+	if err != nil {
+		return err
+	}
+	// recorded is true, if the first remaining send is already recorded
+	// in the report, because it was interrupted before its submission.
+	recorded := false
+	if len(hashes) > 0 {
+		last := len(hashes) - 1
+		if info.Frontier != hashes[last] {
+			resumeInfo := info
+			_, err := resumeInfo.Send(sends[last].amount, sends[last].recipient)
+			if err != nil || resumeInfo.Frontier != hashes[last] {
+				return fmt.Errorf("the account has changed since the last send of %s; check the report manually", reportPath)
+			}
+			fmt.Fprintf(os.Stderr, "Block %s has not been submitted yet; retrying.\n", hashes[last])
+			hashes = hashes[:last]
+			recorded = true
+		}
+	}
+	remaining := sends[len(hashes):]
+	if len(remaining) == 0 {
+		fmt.Fprintf(os.Stderr, "All sends have already been done; see %s.\n", reportPath)
+		return nil
+	}
+	var total atto.Amount
+	for _, send := range remaining {
+		total = total.Add(send.amount)
+	}
+	if total.Cmp(info.Balance) > 0 {
+		return fmt.Errorf("%w: sending %s NANO requires %s NANO more",
+			atto.ErrInsufficientBalance, total.Text(atto.Mnano),
+			total.Sub(info.Balance).Text(atto.Mnano))
+	}
+	question := fmt.Sprintf("Send a total of %s NANO to %d recipients?", total.Text(atto.Mnano), len(remaining))
+	if err = letUserConfirm(question); err != nil {
+		return err
+	}
+
+	report, err := os.OpenFile(reportPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer report.Close()
+	for i, send := range remaining {
+		if err = sendBatchRow(&info, send, privateKey, report, recorded && i == 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendBatchRow creates a send block for send and records it in report
+// before submitting it, unless recorded is true. This way the report
+// contains every block, that may have been submitted. The report is
+// only ever appended to, so that it cannot lose the record of a
+// submitted block.
+func sendBatchRow(info *atto.AccountInfo, send batchSend, privateKey *big.Int, report *os.File, recorded bool) error {
+	fmt.Fprintf(os.Stderr, "Sending %s NANO to %s... ", send.amount.Text(atto.Mnano), send.recipient)
+	block, err := info.Send(send.amount, send.recipient)
+	if err != nil {
+		return err
+	}
+	if err = block.Sign(privateKey); err != nil {
+		return err
+	}
+	if err = fillWork(&block); err != nil {
+		return err
+	}
+	if !recorded {
+		if err = writeBatchReport(report, []batchSend{send}, []string{info.Frontier}); err != nil {
+			return err
+		}
+	}
+	if err = submit(block); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "done")
+	return nil
+}
+
+// readBatchFile reads and validates the rows of a batch-send file. Each
+// row consists of an address and an amount of NANO.
+func readBatchFile(path string) ([]batchSend, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	records, err := readCSV(file, 2)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s contains no sends", path)
+	}
+	sends := make([]batchSend, len(records))
+	for i, record := range records {
+		if err = atto.ValidateAddress(record[0]); err != nil {
+			return nil, fmt.Errorf("row %d: %v", i+1, err)
+		}
+		amount, err := atto.ParseAmount(record[1], atto.Mnano)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %v", i+1, err)
+		} else if amount.IsZero() {
+			return nil, fmt.Errorf("row %d: %v", i+1, atto.ErrZeroAmount)
+		}
+		sends[i] = batchSend{record[0], amount}
+	}
+	return sends, nil
+}
+
+// readBatchReport reads the block hashes from the report at path. It
+// ensures, that the report belongs to sends. If no report exists yet,
+// no hashes are returned.
+func readBatchReport(path string, sends []batchSend) ([]string, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	records, err := readCSV(file, 3)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if len(records) > len(sends) {
+		return nil, fmt.Errorf("%s contains more sends than the batch-send file", path)
+	}
+	hashes := make([]string, len(records))
+	for i, record := range records {
+		if record[0] != sends[i].recipient || record[1] != sends[i].amount.Text(atto.Mnano) {
+			return nil, fmt.Errorf("row %d of %s does not match the batch-send file", i+1, path)
+		}
+		hashes[i] = record[2]
+	}
+	return hashes, nil
+}
+
+// writeBatchReport appends a line with the recipient, amount and block
+// hash to report for each of sends and flushes it to disk.
+func writeBatchReport(report *os.File, sends []batchSend, hashes []string) error {
+	w := csv.NewWriter(report)
+	for i, send := range sends {
+		w.Write([]string{send.recipient, send.amount.Text(atto.Mnano), hashes[i]})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return report.Sync()
+}
+
+// readCSV reads all records from r, which must have fields fields
+// each. Surrounding whitespace of fields is removed.
+func readCSV(r io.Reader, fields int) ([][]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = fields
+	reader.Comment = '#'
+	records, err := reader.ReadAll()
+	for _, record := range records {
+		for i := range record {
+			record[i] = strings.TrimSpace(record[i])
+		}
+	}
+	return records, err
+}
@@ -1,5 +1,11 @@
 package main
 
+import (
+	"time"
+
+	"github.com/codesoap/atto"
+)
+
 var (
 	// The node that is used to interact with the nano network. See
 	// e.g. https://publicnodes.somenano.com to find public nodes
@@ -11,14 +17,47 @@ var (
 	// https://blocklattice.io/representatives to find representatives.
 	defaultRepresentative = "nano_1jtx5p8141zjtukz4msp1x93st7nh475f74odj8673qqm96xczmtcnanos1o"
 
-	// workSource specifies where the work for block submission shall
-	// come from. These options are available:
-	// - workSourceLocal: The work is generated on the CPU of the
-	//   current computer.
-	// - workSourceNode: The work is fetched from the node using the
-	//   work_generate action. Make sure that your node supports it.
-	// - workSourceLocalFallback: It is attempted to fetch the work
-	//   from the node, but if this fails, it will be generated on
-	//   the CPU of the current computer.
-	workSource = workSourceLocalFallback
+	// workProviders specify where the work for block submission shall
+	// come from. They are tried in order; if one fails, the next one
+	// is used. These providers are available:
+	// - atto.ClientWorkProvider{Client: client}: The work is fetched
+	//   from the node using the work_generate action. Make sure that
+	//   your node supports it. Use atto.NewClient(URL) instead of
+	//   client to fetch work from another node or a work server.
+	// - atto.LocalWorkProvider{}: The work is generated on the CPU of
+	//   the current computer. Set Options, e.g. to
+	//   atto.WorkOptions{Workers: 2, Throttle: 0.5}, to limit the CPU
+	//   usage.
+	// - atto.CommandWorkProvider{Name: "CMD", Args: []string{...}}:
+	//   The work is printed by an external command, which receives
+	//   the work root hash and threshold as its last arguments.
+	// - atto.RaceWorkProvider{Providers: []atto.WorkProvider{...}}:
+	//   All given providers are started at once and the first valid
+	//   result is used.
+	// - atto.PriorityWorkProvider{Provider: ..., Multiplier: 2}:
+	//   The work of the given provider reaches a higher difficulty, so
+	//   that blocks are prioritized when the network is saturated. Set
+	//   ActiveDifficultyClient: client to follow the difficulty
+	//   currently required by the network, up to MaxMultiplier.
+	workProviders = []atto.WorkProvider{
+		atto.ClientWorkProvider{Client: client},
+		atto.LocalWorkProvider{},
+	}
+
+	// workConcurrency is the maximum number of blocks, whose work is
+	// obtained at the same time, when multiple receivables are
+	// received at once. atto.LocalWorkProvider already uses all CPU
+	// cores for a single block, so higher values mainly help with
+	// remote work providers.
+	workConcurrency = 4
+
+	// workCacheFile is the path of a file, in which work is kept for
+	// the next block of each account. It is filled by the precompute
+	// subcommand and consulted before any work provider is used. If it
+	// is empty, no work is cached.
+	workCacheFile = ""
+
+	// confirmationTimeout is the maximum time to wait for the
+	// confirmation of a block, if the -w flag is given.
+	confirmationTimeout = 2 * time.Minute
 )
@@ -3,9 +3,11 @@ package main
 import (
 	"flag"
 	"fmt"
+	"math"
 	"math/big"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/codesoap/atto"
 )
@@ -14,29 +16,65 @@ var usage = `Usage:
 	atto -v
 	atto n[ew]
 	atto [-a ACCOUNT_INDEX] a[ddress]
-	atto [-a ACCOUNT_INDEX] b[alance]
-	atto [-a ACCOUNT_INDEX] r[epresentative] [NEW_REPRESENTATIVE]
-	atto [-a ACCOUNT_INDEX] [-y] s[end] AMOUNT RECEIVER
+	atto ac[counts] [-from N] [-to M]
+	atto d[iscover] [-gap N]
+	atto p[recompute] [-from N] [-to M]
+	atto [-a ACCOUNT_INDEX] [-w] b[alance]
+	atto [-a ACCOUNT_INDEX] h[istory]
+	atto [-a ACCOUNT_INDEX] [-w] r[epresentative] [NEW_REPRESENTATIVE]
+	atto [-a ACCOUNT_INDEX] [-w] [-y] s[end] AMOUNT|all RECEIVER
+	atto [-a ACCOUNT_INDEX] [-w] [-y] sw[eep] RECEIVER
+	atto [-a ACCOUNT_INDEX] [-w] [-y] batch-send FILE
+	atto [-a ACCOUNT_INDEX] v[erify] [ADDRESS]
 
 If the -v flag is provided, atto will print its version number.
 
 The new subcommand generates a new seed, which can later be used with
 the other subcommands.
 
-The address, balance, representative and send subcommands expect a seed
-as the first line of their standard input. Showing the first address of
-a newly generated key could work like this:
+The address, accounts, discover, precompute, balance, history,
+representative, send, sweep and batch-send subcommands expect a seed as
+the first line of their standard input. The same goes for the verify
+subcommand, if no ADDRESS is given. Showing the first address of a newly
+generated key could work like this:
 atto new | tee seed.txt | atto address
 
-The send subcommand also expects manual confirmation of the transaction,
-unless the -y flag is given.
+The send, sweep and batch-send subcommands also expect manual
+confirmation of the transaction, unless the -y flag is given.
 
-The address subcommand displays addresses for a seed, the balance
-subcommand receives receivable blocks and shows the balance of an
-account, the representative subcommand shows the current representative
-if NEW_REPRESENTATIVE is not given and changes the account's
-representative if it is given and the send subcommand sends funds to an
-address.
+If the -w flag is given, the balance, representative, send, sweep and
+batch-send subcommands wait until their blocks have been confirmed by
+the network before reporting success.
+
+The address subcommand displays addresses for a seed, the accounts
+subcommand shows a table of the accounts with the indexes N to M
+(default 0 to 9) and their balances, receivable amounts and
+representatives, the discover subcommand lists all accounts, that have
+been opened or have receivable funds, until N consecutive unused
+accounts (default 20) have been found, the balance subcommand receives
+receivable blocks and shows the balance of an account, the history
+subcommand lists all blocks of an account, the representative subcommand
+shows the current representative if NEW_REPRESENTATIVE is not given and
+changes the account's representative if it is given and the send
+subcommand sends funds to an address; if AMOUNT is "all", the entire
+balance is sent. The sweep subcommand receives all receivable blocks,
+like the balance subcommand, and then sends the entire balance to
+RECEIVER, which is useful for retiring an account. The batch-send
+subcommand sends funds to all recipients listed in FILE, which must
+contain one ADDRESS,AMOUNT row per send. All rows are validated and the
+total is checked against the balance before anything is sent. Every send
+block is recorded in FILE.report before it is submitted; if batch-send
+is interrupted, running it again continues with the remaining rows. The
+verify subcommand checks every block of an account's chain, from its
+frontier down to its first block, and reports the first inconsistency it
+finds.
+
+The precompute subcommand generates the work for the next block of every
+opened account with the indexes N to M (default 0 to 9) and stores it in
+the work cache, which must be configured in config.go. Later blocks of
+these accounts use the cached work instead of waiting for new work, so
+running precompute in the background after each transaction speeds up
+the next one.
 
 ACCOUNT_INDEX is an optional parameter, which must be a number between 0
 and 4,294,967,295. It allows you to use multiple accounts derived from
@@ -50,53 +88,92 @@ Environment:
 	                          Authentication.
 `
 
-type workSourceType int
-
-const (
-	workSourceLocal workSourceType = iota
-	workSourceNode
-	workSourceLocalFallback
-)
-
 var accountIndexFlag uint
 var yFlag bool
+var wFlag bool
+
+// client is used for all communication with node.
+var client = atto.NewClient(node)
 
 func init() {
 	var vFlag bool
 	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
 	flag.UintVar(&accountIndexFlag, "a", 0, "")
 	flag.BoolVar(&yFlag, "y", false, "")
+	flag.BoolVar(&wFlag, "w", false, "")
 	flag.BoolVar(&vFlag, "v", false, "")
 	flag.Parse()
 	if vFlag {
 		fmt.Println("1.6.0")
 		os.Exit(0)
 	}
-	if accountIndexFlag >= 1<<32 || flag.NArg() < 1 {
+	if uint64(accountIndexFlag) > math.MaxUint32 || flag.NArg() < 1 {
 		flag.Usage()
 		os.Exit(1)
 	}
 	var ok bool
 	switch flag.Arg(0)[:1] {
-	case "n", "a", "b":
+	case "b":
+		if isBatchSend() {
+			ok = flag.NArg() == 2
+		} else {
+			ok = flag.NArg() == 1
+		}
+	case "a":
+		if isAccounts() {
+			ok = parseAccountsFlags()
+		} else {
+			ok = flag.NArg() == 1
+		}
+	case "n", "h":
 		ok = flag.NArg() == 1
 	case "r":
 		ok = flag.NArg() == 1 || flag.NArg() == 2
 	case "s":
-		ok = flag.NArg() == 3
+		if isSweep() {
+			ok = flag.NArg() == 2
+		} else {
+			ok = flag.NArg() == 3
+		}
+	case "d":
+		ok = parseDiscoverFlags()
+	case "p":
+		ok = parseAccountsFlags()
+	case "v":
+		ok = flag.NArg() == 1 || flag.NArg() == 2
 	}
 	if !ok {
 		flag.Usage()
 		os.Exit(1)
 	}
-	setUpNodeAuthentication()
+	setUpClient()
+}
+
+// isAccounts reports whether the accounts subcommand was requested. It
+// shares its first letter with the address subcommand.
+func isAccounts() bool {
+	return strings.HasPrefix(flag.Arg(0), "ac")
 }
 
-func setUpNodeAuthentication() {
+// isSweep reports whether the sweep subcommand was requested. It
+// shares its first letter with the send subcommand.
+func isSweep() bool {
+	return strings.HasPrefix(flag.Arg(0), "sw")
+}
+
+// isBatchSend reports whether the batch-send subcommand was requested.
+// It shares its first letter with the balance subcommand.
+func isBatchSend() bool {
+	return flag.Arg(0) == "batch-send"
+}
+
+func setUpClient() {
+	// Don't trust the node to report receivable amounts correctly.
+	client.VerifyReceivables = true
 	if os.Getenv("ATTO_BASIC_AUTH_USERNAME") != "" {
 		username := os.Getenv("ATTO_BASIC_AUTH_USERNAME")
 		password := os.Getenv("ATTO_BASIC_AUTH_PASSWORD")
-		atto.RequestInterceptor = func(request *http.Request) error {
+		client.RequestInterceptor = func(request *http.Request) error {
 			request.SetBasicAuth(username, password)
 			return nil
 		}
@@ -109,9 +186,19 @@ func main() {
 	case "n":
 		err = printNewSeed()
 	case "a":
-		err = printAddress()
+		if isAccounts() {
+			err = printAccounts()
+		} else {
+			err = printAddress()
+		}
 	case "b":
-		err = printBalance()
+		if isBatchSend() {
+			err = sendBatch()
+		} else {
+			err = printBalance()
+		}
+	case "h":
+		err = printHistory()
 	case "r":
 		if flag.NArg() == 1 {
 			err = printRepresentative()
@@ -119,7 +206,17 @@ func main() {
 			err = changeRepresentative()
 		}
 	case "s":
-		err = sendFunds()
+		if isSweep() {
+			err = sweep()
+		} else {
+			err = sendFunds()
+		}
+	case "d":
+		err = discoverAccounts()
+	case "p":
+		err = precomputeWork()
+	case "v":
+		err = verifyChain()
 	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -164,55 +261,44 @@ func printBalance() error {
 	if err != nil {
 		return err
 	}
-	firstReceive := false // Is this the very first block of the account?
-	info, err := account.FetchAccountInfo(node)
+	info, err := receiveAll(account, privateKey)
 	if err == atto.ErrAccountNotFound {
-		// Needed for printing balance, even if there are no receivable blocks:
-		info.Balance = "0"
-
-		firstReceive = true
+		fmt.Println("0 NANO")
+		return nil
 	} else if err != nil {
 		return err
 	}
-	receivables, err := account.FetchReceivable(node)
+	fmt.Println(info.Balance.Text(atto.Mnano), "NANO")
+	return nil
+}
+
+func printHistory() error {
+	seed, err := getSeed()
 	if err != nil {
 		return err
 	}
-	for _, receivable := range receivables {
-		txt := "Creating receive block for %s from %s... "
-		amount, ok := big.NewInt(0).SetString(receivable.Amount, 10)
-		if !ok {
-			return fmt.Errorf("cannot parse '%s' as an integer", receivable.Amount)
-		}
-		fmt.Fprintf(os.Stderr, txt, rawToNanoString(amount), receivable.Source)
-		var block atto.Block
-		if firstReceive {
-			fmt.Fprintf(os.Stderr, "opening account... ")
-			info, block, err = account.FirstReceive(receivable, defaultRepresentative)
-			firstReceive = false
-		} else {
-			block, err = info.Receive(receivable)
-		}
+	privateKey, err := atto.NewPrivateKey(seed, uint32(accountIndexFlag))
+	if err != nil {
+		return err
+	}
+	account, err := atto.NewAccount(privateKey)
+	if err != nil {
+		return err
+	}
+	var options atto.HistoryOptions
+	for {
+		history, err := client.FetchHistory(account, options)
 		if err != nil {
 			return err
 		}
-		if err = block.Sign(privateKey); err != nil {
-			return err
-		}
-		if err = fillWork(&block, node); err != nil {
-			return err
+		for _, entry := range history.Entries {
+			printHistoryEntry(entry)
 		}
-		if err = block.Submit(node); err != nil {
-			return err
+		if history.Previous == "" {
+			return nil
 		}
-		fmt.Fprintln(os.Stderr, "done")
+		options.Head = history.Previous
 	}
-	newBalance, ok := big.NewInt(0).SetString(info.Balance, 10)
-	if !ok {
-		return fmt.Errorf("cannot parse '%s' as an integer", info.Balance)
-	}
-	fmt.Println(rawToNanoString(newBalance))
-	return nil
 }
 
 func printRepresentative() error {
@@ -228,7 +314,7 @@ func printRepresentative() error {
 	if err != nil {
 		return err
 	}
-	info, err := account.FetchAccountInfo(node)
+	info, err := client.FetchAccountInfo(account)
 	if err != nil {
 		return err
 	}
@@ -238,6 +324,9 @@ func printRepresentative() error {
 
 func changeRepresentative() error {
 	representative := flag.Arg(1)
+	if err := atto.ValidateAddress(representative); err != nil {
+		return err
+	}
 	seed, err := getSeed()
 	if err != nil {
 		return err
@@ -250,7 +339,7 @@ func changeRepresentative() error {
 	if err != nil {
 		return err
 	}
-	info, err := account.FetchAccountInfo(node)
+	info, err := client.FetchAccountInfo(account)
 	if err != nil {
 		return err
 	}
@@ -263,10 +352,10 @@ func changeRepresentative() error {
 	if err = block.Sign(privateKey); err != nil {
 		return err
 	}
-	if err = fillWork(&block, node); err != nil {
+	if err = fillWork(&block); err != nil {
 		return err
 	}
-	if err = block.Submit(node); err != nil {
+	if err = submit(block); err != nil {
 		return err
 	}
 	fmt.Fprintln(os.Stderr, "done")
@@ -274,8 +363,18 @@ func changeRepresentative() error {
 }
 
 func sendFunds() error {
-	amount := flag.Arg(1)
+	sendAll := flag.Arg(1) == "all"
+	var amount atto.Amount
+	if !sendAll {
+		var err error
+		if amount, err = atto.ParseAmount(flag.Arg(1), atto.Mnano); err != nil {
+			return err
+		}
+	}
 	recipient := flag.Arg(2)
+	if err := atto.ValidateAddress(recipient); err != nil {
+		return err
+	}
 	seed, err := getSeed()
 	if err != nil {
 		return err
@@ -288,14 +387,114 @@ func sendFunds() error {
 	if err != nil {
 		return err
 	}
-	if err = letUserVerifySend(amount, recipient); err != nil {
+	if !sendAll {
+		if err = letUserVerifySend(amount, recipient); err != nil {
+			return err
+		}
+	}
+	info, err := client.FetchAccountInfo(account)
+	if err != nil {
+		return err
+	}
+	if sendAll {
+		if info.Balance.IsZero() {
+			return atto.ErrZeroAmount
+		}
+		if err = letUserVerifySend(info.Balance, recipient); err != nil {
+			return err
+		}
+		amount = info.Balance
+	}
+	return send(info, amount, recipient, privateKey)
+}
+
+func sweep() error {
+	recipient := flag.Arg(1)
+	if err := atto.ValidateAddress(recipient); err != nil {
+		return err
+	}
+	seed, err := getSeed()
+	if err != nil {
+		return err
+	}
+	privateKey, err := atto.NewPrivateKey(seed, uint32(accountIndexFlag))
+	if err != nil {
 		return err
 	}
-	info, err := account.FetchAccountInfo(node)
+	account, err := atto.NewAccount(privateKey)
 	if err != nil {
 		return err
 	}
+	info, err := receiveAll(account, privateKey)
+	if err != nil {
+		return err
+	}
+	if info.Balance.IsZero() {
+		return atto.ErrZeroAmount
+	}
+	if err = letUserVerifySend(info.Balance, recipient); err != nil {
+		return err
+	}
+	return send(info, info.Balance, recipient, privateKey)
+}
+
+// receiveAll receives all receivable blocks of account and returns the
+// updated account info. If the account has not been opened and nothing
+// is receivable, atto.ErrAccountNotFound is returned.
+func receiveAll(account atto.Account, privateKey *big.Int) (atto.AccountInfo, error) {
+	firstReceive := false // Is this the very first block of the account?
+	info, err := client.FetchAccountInfo(account)
+	if err == atto.ErrAccountNotFound {
+		firstReceive = true
+	} else if err != nil {
+		return info, err
+	}
+	receivables, err := client.FetchReceivable(account)
+	if err != nil {
+		return info, err
+	}
+
+	// All blocks are created first, so that their work can be obtained
+	// concurrently.
+	blocks := make([]*atto.Block, len(receivables))
+	for i, receivable := range receivables {
+		var block atto.Block
+		if firstReceive && i == 0 {
+			info, block, err = account.FirstReceive(receivable, defaultRepresentative)
+		} else {
+			block, err = info.Receive(receivable)
+		}
+		if err != nil {
+			return info, err
+		}
+		if err = block.Sign(privateKey); err != nil {
+			return info, err
+		}
+		blocks[i] = &block
+	}
+	if err = fillWorks(blocks); err != nil {
+		return info, err
+	}
+	for i, receivable := range receivables {
+		txt := "Submitting receive block for %s NANO from %s... "
+		fmt.Fprintf(os.Stderr, txt, receivable.Amount.Text(atto.Mnano), receivable.Source)
+		if firstReceive && i == 0 {
+			fmt.Fprintf(os.Stderr, "opening account... ")
+		}
+		if err = submit(*blocks[i]); err != nil {
+			return info, err
+		}
+		fmt.Fprintln(os.Stderr, "done")
+	}
+	if firstReceive && len(receivables) == 0 {
+		return info, atto.ErrAccountNotFound
+	}
+	return info, nil
+}
 
+// send creates, signs and submits a send block of amount from the
+// account described by info to recipient.
+func send(info atto.AccountInfo, amount atto.Amount, recipient string, privateKey *big.Int) error {
 	fmt.Fprintf(os.Stderr, "Creating send block... ")
 	block, err := info.Send(amount, recipient)
 	if err != nil {
@@ -304,12 +503,39 @@ func sendFunds() error {
 	if err = block.Sign(privateKey); err != nil {
 		return err
 	}
-	if err = fillWork(&block, node); err != nil {
+	if err = fillWork(&block); err != nil {
 		return err
 	}
-	if err = block.Submit(node); err != nil {
+	if err = submit(block); err != nil {
 		return err
 	}
 	fmt.Fprintln(os.Stderr, "done")
 	return nil
 }
+
+func verifyChain() error {
+	var account atto.Account
+	if flag.NArg() == 2 {
+		var err error
+		if account, err = atto.NewAccountFromAddress(flag.Arg(1)); err != nil {
+			return err
+		}
+	} else {
+		seed, err := getSeed()
+		if err != nil {
+			return err
+		}
+		privateKey, err := atto.NewPrivateKey(seed, uint32(accountIndexFlag))
+		if err != nil {
+			return err
+		}
+		if account, err = atto.NewAccount(privateKey); err != nil {
+			return err
+		}
+	}
+	if err := client.VerifyChain(account); err != nil {
+		return err
+	}
+	fmt.Printf("The chain of %s is valid.\n", account.Address)
+	return nil
+}
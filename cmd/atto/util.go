@@ -2,8 +2,8 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"fmt"
-	"math/big"
 	"os"
 	"runtime"
 	"strings"
@@ -21,24 +21,31 @@ func getSeed() (string, error) {
 	return strings.TrimSpace(firstLine), nil
 }
 
-func rawToNanoString(raw *big.Int) string {
-	rawPerNano, _ := big.NewInt(0).SetString("1000000000000000000000000000000", 10)
-	absRaw := big.NewInt(0).Abs(raw)
-	integerDigits, fractionalDigits := big.NewInt(0).QuoRem(absRaw, rawPerNano, big.NewInt(0))
-	res := integerDigits.String()
-	if fractionalDigits.Sign() != 0 {
-		fractionalDigitsString := fmt.Sprintf("%030s", fractionalDigits.String())
-		res += "." + strings.TrimRight(fractionalDigitsString, "0")
+func printHistoryEntry(entry atto.HistoryEntry) {
+	date := entry.Timestamp.Format("2006-01-02 15:04")
+	amount := entry.Amount.Text(atto.Mnano)
+	switch entry.SubType {
+	case atto.SubTypeSend:
+		fmt.Printf("%s sent %s NANO to %s\n", date, amount, entry.Counterparty)
+	case atto.SubTypeReceive:
+		fmt.Printf("%s received %s NANO from %s\n", date, amount, entry.Counterparty)
+	case atto.SubTypeChange:
+		fmt.Printf("%s changed representative to %s\n", date, entry.Counterparty)
+	case atto.SubTypeEpoch:
+		block, _ := entry.Block.(atto.Block)
+		fmt.Printf("%s upgraded to epoch v%d\n", date, block.EpochVersion())
 	}
-	if raw.Sign() < 0 {
-		return "-" + res + " NANO"
-	}
-	return res + " NANO"
 }
 
-func letUserVerifySend(amount, recipient string) (err error) {
+func letUserVerifySend(amount atto.Amount, recipient string) error {
+	return letUserConfirm(fmt.Sprintf("Send %s NANO to %s?", amount.Text(atto.Mnano), recipient))
+}
+
+// letUserConfirm asks the user to confirm question and exits, if the
+// user declines. If the -y flag is given, no question is asked.
+func letUserConfirm(question string) (err error) {
 	if !yFlag {
-		fmt.Printf("Send %s NANO to %s? [y/N]: ", amount, recipient)
+		fmt.Printf("%s [y/N]: ", question)
 
 		// Explicitly openning /dev/tty or CONIN$ ensures function, even if
 		// the standard input is not a terminal.
@@ -64,18 +71,51 @@ func letUserVerifySend(amount, recipient string) (err error) {
 	return
 }
 
-func fillWork(block *atto.Block, node string) error {
-	switch workSource {
-	case workSourceLocal:
-		return block.GenerateWork()
-	case workSourceNode:
-		return block.FetchWork(node)
-	case workSourceLocalFallback:
-		if err := block.FetchWork(node); err != nil {
-			fmt.Fprintf(os.Stderr, "Could not fetch work from node (error: %v); generating it on CPU... ", err)
-			return block.GenerateWork()
-		}
-		return nil
+// submit submits block and, if the -w flag is given, waits until it
+// has been confirmed.
+func submit(block atto.Block) error {
+	if err := client.Submit(block); err != nil {
+		return err
+	}
+	if wFlag {
+		fmt.Fprint(os.Stderr, "waiting for confirmation... ")
+		return client.WaitForConfirmation(block, confirmationTimeout)
+	}
+	return nil
+}
+
+func fillWork(block *atto.Block) error {
+	return workProvider().ProvideWork(context.Background(), block)
+}
+
+// fillWorks fills the work of all blocks, obtaining the work of up to
+// workConcurrency blocks at once.
+func fillWorks(blocks []*atto.Block) error {
+	if len(blocks) > 1 {
+		fmt.Fprintf(os.Stderr, "Generating work for %d blocks... ", len(blocks))
+	}
+	err := atto.ProvideWorkConcurrently(context.Background(), workProvider(), blocks, workConcurrency)
+	if err == nil && len(blocks) > 1 {
+		fmt.Fprintln(os.Stderr, "done")
+	}
+	return err
+}
+
+func workProvider() atto.WorkProvider {
+	provider := uncachedWorkProvider()
+	if workCacheFile != "" {
+		provider = cachingWorkProvider{provider}
+	}
+	return provider
+}
+
+// uncachedWorkProvider tries the configured workProviders in order,
+// without using workCacheFile.
+func uncachedWorkProvider() atto.WorkProvider {
+	return atto.FallbackWorkProvider{
+		Providers: workProviders,
+		OnFailure: func(_ atto.WorkProvider, err error) {
+			fmt.Fprintf(os.Stderr, "Could not get work (error: %v); trying next work provider... ", err)
+		},
 	}
-	return fmt.Errorf("unknown work source")
 }
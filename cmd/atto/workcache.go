@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/codesoap/atto"
+)
+
+// workCacheMutex serializes the accesses to workCacheFile of this
+// process. Other processes may still interfere, but since the file is
+// synced and replaced atomically, this can only cost an entry.
+var workCacheMutex sync.Mutex
+
+// workCacheEntry is the work for the next block of Account.
+type workCacheEntry struct {
+	Account string `json:"account"`
+	Work    string `json:"work"`
+}
+
+// readWorkCache returns the entries of workCacheFile by their work
+// roots. A missing file is an empty cache. So is a file, that cannot
+// be parsed; it is replaced by the next update.
+func readWorkCache() (map[string]workCacheEntry, error) {
+	cache := make(map[string]workCacheEntry)
+	data, err := ioutil.ReadFile(workCacheFile)
+	if os.IsNotExist(err) {
+		return cache, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &cache); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring unreadable work cache %s: %v\n", workCacheFile, err)
+		return make(map[string]workCacheEntry), nil
+	}
+	return cache, nil
+}
+
+// updateWorkCache applies update to the entries of workCacheFile and
+// replaces the file with the result.
+func updateWorkCache(update func(cache map[string]workCacheEntry)) error {
+	workCacheMutex.Lock()
+	defer workCacheMutex.Unlock()
+	cache, err := readWorkCache()
+	if err != nil {
+		return err
+	}
+	update(cache)
+	data, err := json.MarshalIndent(cache, "", "\t")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(workCacheFile)
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, filepath.Base(workCacheFile)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	// Without syncing, the renamed file could be empty after a crash.
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), workCacheFile)
+}
+
+// cachedWork returns the cached work for block, if there is an entry
+// for its work root and it reaches the threshold of block.
+func cachedWork(block atto.Block) (string, bool) {
+	root, err := block.WorkRoot()
+	if err != nil {
+		return "", false
+	}
+	workCacheMutex.Lock()
+	cache, err := readWorkCache()
+	workCacheMutex.Unlock()
+	if err != nil {
+		return "", false
+	}
+	block.Work = cache[strings.ToUpper(root)].Work
+	if block.ValidateWork() != nil {
+		return "", false
+	}
+	return block.Work, true
+}
+
+// cachingWorkProvider uses the work of workCacheFile, if it is valid,
+// and obtains it from provider otherwise. Used entries are removed,
+// because a work root can only be used for a single block.
+type cachingWorkProvider struct {
+	provider atto.WorkProvider
+}
+
+func (p cachingWorkProvider) ProvideWork(ctx context.Context, block *atto.Block) error {
+	work, ok := cachedWork(*block)
+	if !ok {
+		return p.provider.ProvideWork(ctx, block)
+	}
+	block.Work = work
+	root, _ := block.WorkRoot()
+	if err := updateWorkCache(func(cache map[string]workCacheEntry) { delete(cache, strings.ToUpper(root)) }); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not update work cache: %v\n", err)
+	}
+	return nil
+}
+
+func precomputeWork() error {
+	if workCacheFile == "" {
+		return fmt.Errorf("workCacheFile is not set in config.go")
+	}
+	seed, err := getSeed()
+	if err != nil {
+		return err
+	}
+	var accounts []atto.Account
+	for index := uint64(fromFlag); index <= uint64(toFlag); index++ {
+		privateKey, err := atto.NewPrivateKey(seed, uint32(index))
+		if err != nil {
+			return err
+		}
+		account, err := atto.NewAccount(privateKey)
+		if err != nil {
+			return err
+		}
+		accounts = append(accounts, account)
+	}
+	// Unlike FetchFrontiers, FetchAccountInfos verifies the frontiers,
+	// so no work is wasted on made up work roots.
+	infos, err := client.FetchAccountInfos(accounts)
+	if err != nil {
+		return err
+	}
+	frontiers := make([]string, len(infos))
+	for i, info := range infos {
+		frontiers[i] = info.Frontier
+	}
+	if err = pruneWorkCache(accounts, frontiers); err != nil {
+		return err
+	}
+	// The cache is filled here, so it must not be used by the provider.
+	provider := uncachedWorkProvider()
+	for i, account := range accounts {
+		if frontiers[i] == "" {
+			continue // The account has not been opened.
+		}
+		// Work for a send block is valid for all following blocks.
+		block := atto.Block{
+			Account:  account.Address,
+			Previous: frontiers[i],
+			SubType:  atto.SubTypeSend,
+		}
+		if _, ok := cachedWork(block); ok {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Precomputing work for %s... ", account.Address)
+		if err = provider.ProvideWork(context.Background(), &block); err != nil {
+			return err
+		}
+		root, _ := block.WorkRoot()
+		err = updateWorkCache(func(cache map[string]workCacheEntry) {
+			cache[strings.ToUpper(root)] = workCacheEntry{account.Address, block.Work}
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, "done")
+	}
+	return nil
+}
+
+// pruneWorkCache removes the entries of accounts, whose work roots are
+// no longer the frontiers of these accounts. Entries of other accounts
+// are kept.
+func pruneWorkCache(accounts []atto.Account, frontiers []string) error {
+	current := make(map[string]string, len(accounts))
+	for i, account := range accounts {
+		current[account.Address] = strings.ToUpper(frontiers[i])
+	}
+	return updateWorkCache(func(cache map[string]workCacheEntry) {
+		for root, entry := range cache {
+			if frontier, ok := current[entry.Account]; ok && frontier != root {
+				delete(cache, root)
+			}
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package atto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ErrConfirmationTimeout is used when a block has not been confirmed
+// within the given timeout.
+var ErrConfirmationTimeout = fmt.Errorf("block has not been confirmed in time")
+
+// confirmationPollInterval is the time waited between two block_info
+// requests while waiting for confirmation.
+var confirmationPollInterval = time.Second
+
+// WaitForConfirmation polls the given node until b is confirmed. If b
+// is not confirmed within timeout, ErrConfirmationTimeout is returned.
+func (b Block) WaitForConfirmation(node string, timeout time.Duration) error {
+	return nodeClient(node).WaitForConfirmation(b, timeout)
+}
+
+// WaitForConfirmationContext is like WaitForConfirmation, but waits
+// until ctx is done instead of using a timeout. In this case ctx.Err()
+// is returned.
+func (b Block) WaitForConfirmationContext(ctx context.Context, node string) error {
+	return nodeClient(node).WaitForConfirmationContext(ctx, b)
+}
+
+// WaitForConfirmation polls the node until b is confirmed. If b is not
+// confirmed within timeout, ErrConfirmationTimeout is returned.
+func (c *Client) WaitForConfirmation(b Block, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := c.WaitForConfirmationContext(ctx, b)
+	if err == context.DeadlineExceeded {
+		err = ErrConfirmationTimeout
+	}
+	return err
+}
+
+// WaitForConfirmationContext is like WaitForConfirmation, but waits
+// until ctx is done instead of using a timeout. In this case ctx.Err()
+// is returned.
+func (c *Client) WaitForConfirmationContext(ctx context.Context, b Block) error {
+	hash, err := b.Hash()
+	if err != nil {
+		return err
+	}
+	for {
+		confirmed, err := c.isConfirmed(ctx, hash)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		} else if err != nil {
+			return err
+		} else if confirmed {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(confirmationPollInterval):
+		}
+	}
+}
+
+// isConfirmed uses the block_info RPC to find out whether the block
+// with the given hash has been confirmed. Blocks that are not yet
+// known to the node are reported as unconfirmed.
+func (c *Client) isConfirmed(ctx context.Context, hash string) (bool, error) {
+	requestBody := fmt.Sprintf(`{`+
+		`"action": "block_info",`+
+		`"json_block": "true",`+
+		`"hash": "%s"`+
+		`}`, hash)
+	responseBytes, err := c.doRPC(ctx, requestBody)
+	if err != nil {
+		return false, err
+	}
+	var block blockInfo
+	if err = json.Unmarshal(responseBytes, &block); err != nil {
+		return false, err
+	}
+	// Need to check block.Error because of
+	// https://github.com/nanocurrency/nano-node/issues/1782.
+	if block.Error == "Block not found" {
+		return false, nil
+	} else if block.Error != "" {
+		return false, fmt.Errorf("could not get block info: %s", block.Error)
+	}
+	return block.Confirmed == "true", nil
+}
@@ -0,0 +1,59 @@
+package atto
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWaitForConfirmation(t *testing.T) {
+	interval := confirmationPollInterval
+	confirmationPollInterval = time.Millisecond
+	defer func() { confirmationPollInterval = interval }()
+	block := newFakeChain(t).blocks[0].Block.(Block)
+
+	var mu sync.Mutex
+	var responses []string
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		// The last response is repeated.
+		response := responses[len(responses)-1]
+		if requests < len(responses) {
+			response = responses[requests]
+		}
+		requests++
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+	client := NewClient(server.URL)
+	respond := func(r ...string) {
+		mu.Lock()
+		responses, requests = r, 0
+		mu.Unlock()
+	}
+
+	// Unknown blocks are unconfirmed, so polling continues.
+	respond(`{"error": "Block not found"}`, `{"confirmed": "false"}`, `{"confirmed": "true"}`)
+	if err := client.WaitForConfirmation(block, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 3 {
+		t.Errorf("expected 3 requests, got %d", requests)
+	}
+
+	respond(`{"error": "Block not found"}`)
+	if err := client.WaitForConfirmation(block, 20*time.Millisecond); err != ErrConfirmationTimeout {
+		t.Errorf("expected %v, got %v", ErrConfirmationTimeout, err)
+	}
+
+	respond(`{"confirmed": "false"}`, `{"error": "Bad hash number"}`)
+	err := client.WaitForConfirmation(block, time.Minute)
+	if err == nil || !strings.Contains(err.Error(), "Bad hash number") {
+		t.Errorf("expected the error of the node, got %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package atto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// DifficultyFromMultiplier returns the difficulty, that requires
+// multiplier times as much work as base on average. See
+// https://docs.nano.org/protocol-design/spam-work-and-prioritization/#difficulty-multiplier
+func DifficultyFromMultiplier(base uint64, multiplier float64) uint64 {
+	if multiplier <= 0 {
+		return 0
+	}
+	// -base is the distance of base to 2^64.
+	distance := float64(-base) / multiplier
+	if distance < 1 {
+		return math.MaxUint64
+	} else if distance >= math.Exp2(64) {
+		return 0
+	}
+	return -uint64(distance)
+}
+
+// MultiplierFromDifficulty returns how many times as much work
+// difficulty requires on average compared to base.
+func MultiplierFromDifficulty(base, difficulty uint64) float64 {
+	return float64(-base) / float64(-difficulty)
+}
+
+// ActiveDifficulty describes the difficulty, that the network currently
+// requires for prompt processing of blocks.
+type ActiveDifficulty struct {
+	// Multiplier is the ratio of NetworkCurrent to NetworkMinimum.
+	Multiplier float64
+
+	NetworkMinimum        uint64
+	NetworkCurrent        uint64
+	NetworkReceiveMinimum uint64
+	NetworkReceiveCurrent uint64
+}
+
+type activeDifficultyResponse struct {
+	Error                 string `json:"error"`
+	Multiplier            string `json:"multiplier"`
+	NetworkMinimum        string `json:"network_minimum"`
+	NetworkCurrent        string `json:"network_current"`
+	NetworkReceiveMinimum string `json:"network_receive_minimum"`
+	NetworkReceiveCurrent string `json:"network_receive_current"`
+}
+
+// For returns the current difficulty for b. It is never lower than the
+// threshold of b.
+func (d ActiveDifficulty) For(b Block) uint64 {
+	current := d.NetworkCurrent
+	if b.WorkThreshold() == ReceiveWorkThreshold {
+		current = d.NetworkReceiveCurrent
+	}
+	return b.minimumDifficulty(current)
+}
+
+// FetchActiveDifficulty fetches the current difficulty of the network
+// using the active_difficulty RPC.
+func (c *Client) FetchActiveDifficulty() (ActiveDifficulty, error) {
+	return c.fetchActiveDifficulty(context.Background())
+}
+
+// FetchActiveDifficultyContext is like FetchActiveDifficulty, but
+// aborts when ctx is done.
+func (c *Client) FetchActiveDifficultyContext(ctx context.Context) (ActiveDifficulty, error) {
+	return c.fetchActiveDifficulty(ctx)
+}
+
+func (c *Client) fetchActiveDifficulty(ctx context.Context) (ActiveDifficulty, error) {
+	responseBytes, err := c.doRPC(ctx, `{"action": "active_difficulty"}`)
+	if err != nil {
+		return ActiveDifficulty{}, err
+	}
+	var response activeDifficultyResponse
+	if err = json.Unmarshal(responseBytes, &response); err != nil {
+		return ActiveDifficulty{}, err
+	}
+	// Need to check response.Error because of
+	// https://github.com/nanocurrency/nano-node/issues/1782.
+	if response.Error != "" {
+		return ActiveDifficulty{}, fmt.Errorf("could not fetch active difficulty: %s", response.Error)
+	}
+	var d ActiveDifficulty
+	if d.Multiplier, err = strconv.ParseFloat(response.Multiplier, 64); err != nil {
+		return ActiveDifficulty{}, fmt.Errorf("cannot parse '%s' as multiplier: %v", response.Multiplier, err)
+	}
+	fields := []struct {
+		in  string
+		out *uint64
+	}{
+		{response.NetworkMinimum, &d.NetworkMinimum},
+		{response.NetworkCurrent, &d.NetworkCurrent},
+		{response.NetworkReceiveMinimum, &d.NetworkReceiveMinimum},
+		{response.NetworkReceiveCurrent, &d.NetworkReceiveCurrent},
+	}
+	for _, field := range fields {
+		if *field.out, err = strconv.ParseUint(field.in, 16, 64); err != nil {
+			return ActiveDifficulty{}, fmt.Errorf("cannot parse '%s' as difficulty: %v", field.in, err)
+		}
+	}
+	return d, nil
+}
+
+// PriorityWorkProvider obtains work of a higher difficulty than the
+// threshold of a block from Provider, which gives the block a higher
+// priority when the network is saturated.
+type PriorityWorkProvider struct {
+	Provider WorkProvider
+
+	// Multiplier is applied to the threshold of each block. Values
+	// below 1 are ignored.
+	Multiplier float64
+
+	// If ActiveDifficultyClient is not nil, the active difficulty of
+	// the network is fetched from it for each block and used, if it is
+	// higher than the difficulty derived from Multiplier. If the active
+	// difficulty cannot be fetched, only Multiplier is used.
+	ActiveDifficultyClient *Client
+
+	// MaxMultiplier limits the active difficulty relative to the
+	// threshold of each block, so that a misbehaving node cannot demand
+	// an endless search. If it is not positive, defaultMaxMultiplier is
+	// used.
+	MaxMultiplier float64
+}
+
+// defaultMaxMultiplier is the default of
+// PriorityWorkProvider.MaxMultiplier. It matches the default limit of
+// the work_generate action of the nano node.
+const defaultMaxMultiplier = 64
+
+// ProvideWork sets the work of block to work of the prioritized
+// difficulty.
+func (p PriorityWorkProvider) ProvideWork(ctx context.Context, block *Block) error {
+	return p.ProvideWorkDifficulty(ctx, block, 0)
+}
+
+// ProvideWorkDifficulty is like ProvideWork, but the work must also
+// reach difficulty.
+func (p PriorityWorkProvider) ProvideWorkDifficulty(ctx context.Context, block *Block, difficulty uint64) error {
+	if p.Multiplier > 1 {
+		if d := DifficultyFromMultiplier(block.WorkThreshold(), p.Multiplier); d > difficulty {
+			difficulty = d
+		}
+	}
+	if p.ActiveDifficultyClient != nil {
+		active, err := p.ActiveDifficultyClient.fetchActiveDifficulty(ctx)
+		if err == nil {
+			maxMultiplier := p.MaxMultiplier
+			if maxMultiplier <= 0 {
+				maxMultiplier = defaultMaxMultiplier
+			}
+			d := active.For(*block)
+			if max := DifficultyFromMultiplier(block.WorkThreshold(), maxMultiplier); d > max {
+				d = max
+			}
+			if d > difficulty {
+				difficulty = d
+			}
+		} else if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return provideWorkDifficulty(ctx, p.Provider, block, difficulty)
+}
@@ -0,0 +1,171 @@
+package atto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDifficultyMultiplier(t *testing.T) {
+	// The values of the examples in the documentation.
+	tests := []struct {
+		base, difficulty uint64
+		multiplier       float64
+	}{
+		{DefaultWorkThreshold, 0xfffffff800000000, 1},
+		{DefaultWorkThreshold, 0xfffffffc00000000, 2},
+		{DefaultWorkThreshold, 0xfffffff000000000, 0.5},
+		{ReceiveWorkThreshold, DefaultWorkThreshold, 64},
+	}
+	for _, test := range tests {
+		if d := DifficultyFromMultiplier(test.base, test.multiplier); d != test.difficulty {
+			t.Errorf("expected difficulty %016x for multiplier %v, got %016x", test.difficulty, test.multiplier, d)
+		}
+		if m := MultiplierFromDifficulty(test.base, test.difficulty); math.Abs(m-test.multiplier) > 1e-9 {
+			t.Errorf("expected multiplier %v for difficulty %016x, got %v", test.multiplier, test.difficulty, m)
+		}
+	}
+}
+
+func TestFetchActiveDifficulty(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"multiplier": "1.5",
+			"network_current": "fffffffaaaaaaaab",
+			"network_minimum": "fffffff800000000",
+			"network_receive_current": "fffffff07c1f07c2",
+			"network_receive_minimum": "fffffe0000000000"
+		}`))
+	}))
+	defer server.Close()
+	active, err := NewClient(server.URL).FetchActiveDifficulty()
+	if err != nil {
+		t.Fatal(err)
+	}
+	send := Block{SubType: SubTypeSend}
+	receive := Block{SubType: SubTypeReceive}
+	if active.Multiplier != 1.5 || active.For(send) != 0xfffffffaaaaaaaab || active.For(receive) != 0xfffffff07c1f07c2 {
+		t.Errorf("unexpected active difficulty %+v", active)
+	}
+}
+
+// testWorkBlock is a send block, whose work root has known work.
+var testWorkBlock = Block{
+	Account:  "nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh",
+	Previous: "991CF190094C00F0B68E2E5F75F6BEE95A2E0BD93CEAA4A6734DB9F19B728948",
+	SubType:  SubTypeSend,
+}
+
+// testWork reaches ffffffff25bc7fc3 for testWorkBlock.
+const testWork = "0000000006ebdff8"
+
+// fixedWorkProvider always provides the same work and does not
+// implement DifficultyWorkProvider.
+type fixedWorkProvider struct {
+	work string
+}
+
+func (p fixedWorkProvider) ProvideWork(ctx context.Context, block *Block) error {
+	block.Work = p.work
+	return nil
+}
+
+// recordingWorkProvider records the difficulty it is asked for.
+type recordingWorkProvider struct {
+	difficulty uint64
+}
+
+func (p *recordingWorkProvider) ProvideWork(ctx context.Context, block *Block) error {
+	return p.ProvideWorkDifficulty(ctx, block, 0)
+}
+
+func (p *recordingWorkProvider) ProvideWorkDifficulty(ctx context.Context, block *Block, difficulty uint64) error {
+	p.difficulty = difficulty
+	block.Work = testWork
+	return nil
+}
+
+func TestProvideWorkDifficultyFallback(t *testing.T) {
+	provider := fixedWorkProvider{testWork}
+	block := testWorkBlock
+	if err := provideWorkDifficulty(context.Background(), provider, &block, 0xffffffff00000000); err != nil {
+		t.Fatal(err)
+	}
+	if block.Work != testWork {
+		t.Errorf("expected work %s, got '%s'", testWork, block.Work)
+	}
+	block = testWorkBlock
+	err := provideWorkDifficulty(context.Background(), provider, &block, 0xffffffffff000000)
+	if err == nil || block.Work != "" {
+		t.Errorf("expected error and no work, got %v and '%s'", err, block.Work)
+	}
+}
+
+func TestPriorityWorkProvider(t *testing.T) {
+	recorder := &recordingWorkProvider{}
+	provider := PriorityWorkProvider{Provider: recorder, Multiplier: 2}
+	block := testWorkBlock
+	if err := provider.ProvideWork(context.Background(), &block); err != nil {
+		t.Fatal(err)
+	}
+	if recorder.difficulty != 0xfffffffc00000000 {
+		t.Errorf("expected difficulty fffffffc00000000, got %016x", recorder.difficulty)
+	}
+
+	// A misbehaving node demands an impossible difficulty.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"multiplier": "1.5",
+			"network_current": "ffffffffffffffff",
+			"network_minimum": "fffffff800000000",
+			"network_receive_current": "ffffffffffffffff",
+			"network_receive_minimum": "fffffe0000000000"
+		}`))
+	}))
+	defer server.Close()
+	provider.ActiveDifficultyClient = NewClient(server.URL)
+	provider.MaxMultiplier = 8
+	if err := provider.ProvideWork(context.Background(), &block); err != nil {
+		t.Fatal(err)
+	}
+	if recorder.difficulty != 0xffffffff00000000 {
+		t.Errorf("expected difficulty clamped to ffffffff00000000, got %016x", recorder.difficulty)
+	}
+}
+
+func TestFetchWorkDifficulty(t *testing.T) {
+	var requested string
+	work := testWork
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Difficulty string `json:"difficulty"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		requested = request.Difficulty
+		fmt.Fprintf(w, `{"work": "%s"}`, work)
+	}))
+	defer server.Close()
+	client := NewClient(server.URL)
+	block := testWorkBlock
+	if err := client.FetchWorkDifficulty(&block, 0xffffffff00000000); err != nil {
+		t.Fatal(err)
+	}
+	if requested != "ffffffff00000000" || block.Work != testWork {
+		t.Errorf("unexpected difficulty '%s' or work '%s'", requested, block.Work)
+	}
+
+	// The node returns work, that does not reach the difficulty.
+	block = testWorkBlock
+	if err := client.FetchWorkDifficulty(&block, 0xffffffffff000000); err == nil || block.Work != "" {
+		t.Errorf("expected error and no work, got %v and '%s'", err, block.Work)
+	}
+
+	// The threshold of the block is not sent explicitly.
+	if err := client.FetchWorkDifficulty(&block, 0); err != nil || requested != "" {
+		t.Errorf("expected no explicit difficulty, got %v and '%s'", err, requested)
+	}
+}
@@ -0,0 +1,96 @@
+package atto
+
+import "context"
+
+// defaultDiscoveryGap is the number of consecutive unused accounts,
+// after which discovery stops, if DiscoveryOptions.Gap is not set.
+const defaultDiscoveryGap = 20
+
+// DiscoveryOptions control the search for used accounts of a seed.
+type DiscoveryOptions struct {
+	// Start is the first account index to check.
+	Start uint32
+
+	// Gap is the number of consecutive unused accounts, after which
+	// the search stops. If it is zero, defaultDiscoveryGap is used.
+	Gap uint32
+}
+
+// DiscoveredAccount is an account, that has been opened or has
+// receivable funds.
+type DiscoveredAccount struct {
+	Index   uint32
+	Account Account
+
+	// Frontier is empty, if the account has not been opened yet.
+	Frontier string
+
+	Receivable Amount
+}
+
+// DiscoverAccounts derives the accounts of seed in order, starting at
+// options.Start, and returns all that have been opened or have
+// receivable funds. The search stops after options.Gap consecutive
+// unused accounts have been found.
+//
+// The accounts are checked in batches of options.Gap, using
+// FetchFrontiers and FetchBalances. The returned
+// information is not verified.
+func (c *Client) DiscoverAccounts(seed string, options DiscoveryOptions) ([]DiscoveredAccount, error) {
+	return c.discoverAccounts(context.Background(), seed, options)
+}
+
+// DiscoverAccountsContext is like DiscoverAccounts, but aborts when ctx
+// is done.
+func (c *Client) DiscoverAccountsContext(ctx context.Context, seed string, options DiscoveryOptions) ([]DiscoveredAccount, error) {
+	return c.discoverAccounts(ctx, seed, options)
+}
+
+func (c *Client) discoverAccounts(ctx context.Context, seed string, options DiscoveryOptions) ([]DiscoveredAccount, error) {
+	gap := options.Gap
+	if gap == 0 {
+		gap = defaultDiscoveryGap
+	}
+	var discovered []DiscoveredAccount
+	index := uint64(options.Start)
+	unused := uint32(0)
+	for unused < gap && index < 1<<32 {
+		var accounts []Account
+		for i := index; i < index+uint64(gap) && i < 1<<32; i++ {
+			privateKey, err := NewPrivateKey(seed, uint32(i))
+			if err != nil {
+				return nil, err
+			}
+			account, err := NewAccount(privateKey)
+			if err != nil {
+				return nil, err
+			}
+			accounts = append(accounts, account)
+		}
+		frontiers, err := c.fetchFrontiers(ctx, accounts)
+		if err != nil {
+			return nil, err
+		}
+		balances, err := c.fetchBalances(ctx, accounts)
+		if err != nil {
+			return nil, err
+		}
+		for i, account := range accounts {
+			if frontiers[i] == "" && balances[i].Receivable.IsZero() {
+				if unused++; unused == gap {
+					break
+				}
+				continue
+			}
+			unused = 0
+			discovered = append(discovered, DiscoveredAccount{
+				Index:      uint32(index) + uint32(i),
+				Account:    account,
+				Frontier:   frontiers[i],
+				Receivable: balances[i].Receivable,
+			})
+		}
+		index += uint64(len(accounts))
+	}
+	return discovered, nil
+}
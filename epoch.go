@@ -0,0 +1,47 @@
+package atto
+
+import "strings"
+
+// See https://docs.nano.org/releases/network-upgrades/#epoch-blocks
+var (
+	// epochV1Link is "epoch v1 block" encoded as ASCII and padded with
+	// zeros.
+	epochV1Link = "65706F636820763120626C6F636B000000000000000000000000000000000000"
+
+	// epochV2Link is "epoch v2 block" encoded as ASCII and padded with
+	// zeros.
+	epochV2Link = "65706F636820763220626C6F636B000000000000000000000000000000000000"
+
+	// epochSigners maps the epoch versions to the accounts signing
+	// their epoch blocks on the live network.
+	epochSigners = map[int]string{
+		1: "nano_3t6k35gi95xu6tergt6p69ck76ogmitsa8mnijtpxm9fkcm736xtoncuohr3",
+		2: "nano_3qb6o6i1tkzr6jwr5s7eehfxwg9x6eemitdinbpi7u8bjjwsgqfj4wzser3x",
+	}
+)
+
+// EpochVersion returns the epoch version, if b's link marks it as an
+// epoch block, and 0 otherwise.
+func (b Block) EpochVersion() int {
+	switch {
+	case strings.EqualFold(b.Link, epochV1Link):
+		return 1
+	case strings.EqualFold(b.Link, epochV2Link):
+		return 2
+	}
+	return 0
+}
+
+// verifyEpochSignature verifies the signature of b against the signer
+// of its epoch version.
+func (b *Block) verifyEpochSignature() error {
+	signer, ok := epochSigners[b.EpochVersion()]
+	if !ok {
+		return errInvalidSignature
+	}
+	account, err := NewAccountFromAddress(signer)
+	if err != nil {
+		return err
+	}
+	return b.verifySignatureOf(account.PublicKey)
+}
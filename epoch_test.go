@@ -0,0 +1,174 @@
+package atto
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// The epoch blocks of the test chain are signed by these accounts,
+// derived from the test seed with the indexes 2 and 3, instead of the
+// epoch signers of the live network.
+const (
+	testEpochV1Signer = "nano_1o9htqz3bwjjxohz7i6411jc5np8js7qr4beoyunkms8pc83nf8jsfmh6x8z"
+	testEpochV2Signer = "nano_3whdn48386uehnq6qgmrwp6efqoab1cde37scmce19ji6cicg1ca5xkaf1kt"
+)
+
+// useTestEpochSigners replaces epochSigners until the test has
+// finished.
+func useTestEpochSigners(t *testing.T) {
+	signers := epochSigners
+	epochSigners = map[int]string{1: testEpochV1Signer, 2: testEpochV2Signer}
+	t.Cleanup(func() { epochSigners = signers })
+}
+
+// testEpochBlocks upgrade the frontier of historyFixture to epoch v1
+// and then v2. Their work only reaches ReceiveWorkThreshold.
+func testEpochBlocks(t *testing.T) (v1, v2 Block) {
+	balance := mustParseAmount(t, "6", Mnano)
+	v1 = Block{
+		Type:           "state",
+		Account:        "nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh",
+		Previous:       "55A278B239C3CBEF5C9A275CF774A74815E0C206526D65A631974F95666E937C",
+		Representative: "nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh",
+		Balance:        balance,
+		Link:           "65706F636820763120626C6F636B000000000000000000000000000000000000",
+		Signature:      "EFCF90A943BC7766BA46F711B726308C80E6369FEC6F5BBA6F1BFAF81AFA9C70EAF0D80E02133BC139336033BD94843B2E0D7FF486B1E55C4FD9F515A399350E",
+		Work:           "0000000000356772",
+		SubType:        SubTypeEpoch,
+	}
+	v2 = v1
+	v2.Previous = "ECC26F77CBA4D6566ACD73FF0DBBE111F6FBEE62DA0DC4DB76B3BD2BEF6B689D"
+	v2.Link = "65706F636820763220626C6F636B000000000000000000000000000000000000"
+	v2.Signature = "9F720B90821D7C7E2ED8872D1B0339C4CB2F8D0595E26028C11B4EDF180DB89CA60DC1DDCBE6C720944EE58394E1F727E919CB6F1099FB8A48719A0E8FA6F104"
+	v2.Work = "00000000007da761"
+	return
+}
+
+func TestEpochVersion(t *testing.T) {
+	tests := []struct {
+		link    string
+		version int
+	}{
+		{"65706F636820763120626C6F636B000000000000000000000000000000000000", 1},
+		{"65706f636820763120626c6f636b000000000000000000000000000000000000", 1},
+		{"65706F636820763220626C6F636B000000000000000000000000000000000000", 2},
+		{"65706F636820763320626C6F636B000000000000000000000000000000000000", 0},
+		{"0000000000000000000000000000000000000000000000000000000000000000", 0},
+	}
+	for _, test := range tests {
+		if version := (Block{Link: test.link}).EpochVersion(); version != test.version {
+			t.Errorf("expected version %d for link %s, got %d", test.version, test.link, version)
+		}
+	}
+}
+
+func TestEpochSignature(t *testing.T) {
+	useTestEpochSigners(t)
+	account, err := NewAccountFromAddress("nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v1, v2 := testEpochBlocks(t)
+	tampered := v2
+	tampered.Representative = testEpochV2Signer
+	wrongSigner := v1
+	wrongSigner.Signature = v2.Signature
+	notEpoch := v1
+	notEpoch.Link = publicKeyString(account)
+	tests := []struct {
+		name  string
+		block Block
+		valid bool
+	}{
+		{"epoch v1", v1, true},
+		{"epoch v2", v2, true},
+		{"tampered", tampered, false},
+		{"wrong signer", wrongSigner, false},
+		{"no epoch link", notEpoch, false},
+	}
+	for _, test := range tests {
+		valid, err := test.block.VerifySignature(account)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		} else if valid != test.valid {
+			t.Errorf("%s: expected valid signature to be %v", test.name, test.valid)
+		}
+	}
+}
+
+func TestEpochWork(t *testing.T) {
+	v1, v2 := testEpochBlocks(t)
+	for _, block := range []Block{v1, v2} {
+		if err := block.ValidateWork(); err != nil {
+			t.Errorf("expected valid work %s for epoch block, got %v", block.Work, err)
+		}
+		block.SubType = SubTypeChange
+		if err := block.ValidateWork(); !errors.Is(err, ErrInsufficientWork) {
+			t.Errorf("expected %v for work %s of change block, got %v", ErrInsufficientWork, block.Work, err)
+		}
+	}
+}
+
+func TestEpochHistory(t *testing.T) {
+	useTestEpochSigners(t)
+	account, err := NewAccountFromAddress("nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Like a real node, the history names the epoch signer in
+	// "account".
+	history := `[
+		{
+			"type": "state",
+			"representative": "nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh",
+			"link": "65706F636820763220626C6F636B000000000000000000000000000000000000",
+			"balance": "6000000000000000000000000000000",
+			"previous": "ECC26F77CBA4D6566ACD73FF0DBBE111F6FBEE62DA0DC4DB76B3BD2BEF6B689D",
+			"subtype": "epoch",
+			"account": "` + testEpochV2Signer + `",
+			"amount": "0",
+			"local_timestamp": "1700000800",
+			"height": "8",
+			"hash": "568849F05C36DEEBD62379B99DE9F4196897022CB9BA574F8C2BB1F905168FD9",
+			"confirmed": "true",
+			"work": "00000000007da761",
+			"signature": "9F720B90821D7C7E2ED8872D1B0339C4CB2F8D0595E26028C11B4EDF180DB89CA60DC1DDCBE6C720944EE58394E1F727E919CB6F1099FB8A48719A0E8FA6F104"
+		},
+		{
+			"type": "state",
+			"representative": "nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh",
+			"link": "65706F636820763120626C6F636B000000000000000000000000000000000000",
+			"balance": "6000000000000000000000000000000",
+			"previous": "55A278B239C3CBEF5C9A275CF774A74815E0C206526D65A631974F95666E937C",
+			"subtype": "epoch",
+			"account": "` + testEpochV1Signer + `",
+			"amount": "0",
+			"local_timestamp": "1700000700",
+			"height": "7",
+			"hash": "ECC26F77CBA4D6566ACD73FF0DBBE111F6FBEE62DA0DC4DB76B3BD2BEF6B689D",
+			"confirmed": "true",
+			"work": "0000000000356772",
+			"signature": "EFCF90A943BC7766BA46F711B726308C80E6369FEC6F5BBA6F1BFAF81AFA9C70EAF0D80E02133BC139336033BD94843B2E0D7FF486B1E55C4FD9F515A399350E"
+		}
+	]`
+	var blocks accountHistoryBlocks
+	if err = json.Unmarshal([]byte(history), &blocks); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := account.toHistoryEntries(blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = verifyHistoryAmounts(entries); err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.SubType != SubTypeEpoch || !entry.Amount.IsZero() || entry.Counterparty != "" {
+			t.Errorf("unexpected epoch entry %+v", entry)
+		}
+		if block := entry.Block.(Block); block.Account != account.Address || block.ValidateWork() != nil {
+			t.Errorf("unexpected epoch block %+v", block)
+		}
+	}
+}
@@ -0,0 +1,514 @@
+package atto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HistoryOptions control which part of an account's history is
+// fetched.
+type HistoryOptions struct {
+	// Count is the maximum number of blocks to fetch. If it is not
+	// positive, defaultHistoryCount is used.
+	Count int
+
+	// Head is the hash of the newest block to fetch. If it is empty,
+	// the frontier of the account is used.
+	Head string
+
+	// Offset is the number of blocks to skip, starting at Head.
+	Offset int
+}
+
+const defaultHistoryCount = 100
+
+// History is a page of the history of an account.
+type History struct {
+	// Entries are sorted from newest to oldest.
+	Entries []HistoryEntry
+
+	// Previous is the hash of the block preceding the last entry. It
+	// can be used as HistoryOptions.Head to fetch the next page. It is
+	// empty if there are no further blocks.
+	Previous string
+}
+
+// HistoryEntry is a verified block of an account's history.
+type HistoryEntry struct {
+	// Block is a Block for state blocks and a LegacyBlock for legacy
+	// blocks.
+	Block AnyBlock
+
+	SubType BlockSubType
+	Hash    string
+
+	// Balance is the balance after the block. Legacy blocks, other
+	// than send blocks, don't contain their balance. For them it is
+	// derived from the next newer entry. If there is none, BalanceKnown
+	// is false.
+	Balance      Amount
+	BalanceKnown bool
+
+	// Amount is the amount by which the balance changed. It is zero
+	// for change and epoch blocks.
+	Amount Amount
+
+	// Counterparty is the receiver of send blocks, the sender of
+	// receive blocks and the new representative of change blocks. It
+	// is empty for epoch blocks.
+	Counterparty string
+
+	Height    uint64
+	Timestamp time.Time
+	Confirmed bool
+
+	// source is the hash of the received send block of receive
+	// entries.
+	source string
+}
+
+type accountHistory struct {
+	Error    string               `json:"error"`
+	History  accountHistoryBlocks `json:"history"`
+	Previous string               `json:"previous"`
+}
+
+type accountHistoryBlocks []accountHistoryBlock
+
+// UnmarshalJSON interprets an empty string as an empty list. This is
+// necessary, because the node returns an empty string instead of a
+// list for accounts without history.
+func (b *accountHistoryBlocks) UnmarshalJSON(in []byte) error {
+	if string(in) == `""` {
+		return nil
+	}
+	var raw []accountHistoryBlock
+	err := json.Unmarshal(in, &raw)
+	*b = accountHistoryBlocks(raw)
+	return err
+}
+
+type accountHistoryBlock struct {
+	Block          AnyBlock `json:"-"`
+	SubTypeName    string   `json:"subtype"`
+	Hash           string   `json:"hash"`
+	Amount         Amount   `json:"amount"`
+	Height         string   `json:"height"`
+	LocalTimestamp string   `json:"local_timestamp"`
+	Confirmed      string   `json:"confirmed"`
+}
+
+// UnmarshalJSON unmarshals the meta data of the history entry and
+// parses the block, which is inlined in the same object.
+//
+// The inlined block differs from the block's own JSON representation:
+// "account" is the counterparty instead of the block's account, legacy
+// open blocks name their account in "opened" and legacy send blocks
+// have a decimal balance. The account of state blocks is unknown here,
+// so it is left to toHistoryEntry.
+func (b *accountHistoryBlock) UnmarshalJSON(in []byte) error {
+	type meta accountHistoryBlock // Prevents infinite recursion.
+	raw := struct {
+		*meta
+		Opened string `json:"opened"`
+	}{meta: (*meta)(b)}
+	if err := json.Unmarshal(in, &raw); err != nil {
+		return err
+	}
+	var err error
+	if b.Block, err = ParseBlock(in); err != nil {
+		return err
+	}
+	if legacy, ok := b.Block.(LegacyBlock); ok {
+		legacy.Account = ""
+		switch legacy.Type {
+		case "open":
+			legacy.Account = raw.Opened
+		case "send":
+			balance, err := ParseAmount(legacy.Balance, Raw)
+			if err != nil {
+				return err
+			}
+			legacy.Balance = fmt.Sprintf("%032X", balance.bigInt())
+		}
+		b.Block = legacy
+	}
+	return nil
+}
+
+type blocksInfo struct {
+	Error  string                    `json:"error"`
+	Blocks map[string]blocksInfoItem `json:"blocks"`
+}
+
+type blocksInfoItem struct {
+	BlockAccount string   `json:"block_account"`
+	Amount       Amount   `json:"amount"`
+	Confirmed    string   `json:"confirmed"`
+	SubTypeName  string   `json:"subtype"`
+	Contents     AnyBlock `json:"-"`
+}
+
+// UnmarshalJSON unmarshals the meta data of the item and parses its
+// contents, which may be a state block or a legacy block.
+func (i *blocksInfoItem) UnmarshalJSON(in []byte) error {
+	type meta blocksInfoItem // Prevents infinite recursion.
+	raw := struct {
+		*meta
+		Contents json.RawMessage `json:"contents"`
+	}{meta: (*meta)(i)}
+	if err := json.Unmarshal(in, &raw); err != nil {
+		return err
+	}
+	var err error
+	i.Contents, err = ParseBlock(raw.Contents)
+	return err
+}
+
+// FetchHistory fetches a page of the history of Account from node.
+//
+// Every returned block is verified by checking its hash and signature.
+// The same goes for the source blocks of receives, which determine
+// their Counterparty and must send to Account. Both state blocks and
+// legacy blocks are supported.
+// Additionally the amounts of consecutive blocks are checked against
+// their balances. To verify the amount of the oldest entry, one block
+// more than requested is fetched. ErrAccountManipulated is returned,
+// if any of these checks fail.
+func (a Account) FetchHistory(node string, options HistoryOptions) (History, error) {
+	return a.fetchHistory(context.Background(), nodeClient(node), options)
+}
+
+// FetchHistoryContext is like FetchHistory, but aborts when ctx is
+// done.
+func (a Account) FetchHistoryContext(ctx context.Context, node string, options HistoryOptions) (History, error) {
+	return a.fetchHistory(ctx, nodeClient(node), options)
+}
+
+// FetchHistory fetches a page of the history of a from the node. See
+// Account.FetchHistory for details.
+func (c *Client) FetchHistory(a Account, options HistoryOptions) (History, error) {
+	return a.fetchHistory(context.Background(), c, options)
+}
+
+// FetchHistoryContext is like FetchHistory, but aborts when ctx is
+// done.
+func (c *Client) FetchHistoryContext(ctx context.Context, a Account, options HistoryOptions) (History, error) {
+	return a.fetchHistory(ctx, c, options)
+}
+
+func (a Account) fetchHistory(ctx context.Context, c *Client, options HistoryOptions) (History, error) {
+	if options.Count <= 0 {
+		options.Count = defaultHistoryCount
+	}
+	// One more block is fetched, so that the amount of the oldest
+	// returned entry can be verified against its predecessor.
+	count := options.Count
+	options.Count++
+	history, err := a.fetchRawHistory(ctx, c, options)
+	if err != nil {
+		return History{}, err
+	}
+	entries, err := a.toHistoryEntries(history.History)
+	if err != nil {
+		return History{}, err
+	}
+	if err = verifyHistoryAmounts(entries); err != nil {
+		return History{}, err
+	}
+	previous := history.Previous
+	if len(entries) > count {
+		previous = entries[count].Hash
+		entries = entries[:count]
+	}
+	if err = c.fillReceiveCounterparties(ctx, a, entries); err != nil {
+		return History{}, err
+	}
+	return History{Entries: entries, Previous: previous}, nil
+}
+
+func (a Account) fetchRawHistory(ctx context.Context, c *Client, options HistoryOptions) (accountHistory, error) {
+	count := options.Count
+	if count <= 0 {
+		count = defaultHistoryCount
+	}
+	requestBody := fmt.Sprintf(`{`+
+		`"action": "account_history",`+
+		`"account": "%s",`+
+		`"count": "%d",`+
+		`"raw": "true"`, a.Address, count)
+	if options.Head != "" {
+		requestBody += fmt.Sprintf(`, "head": "%s"`, options.Head)
+	}
+	if options.Offset > 0 {
+		requestBody += fmt.Sprintf(`, "offset": "%d"`, options.Offset)
+	}
+	requestBody += `}`
+	responseBytes, err := c.doRPC(ctx, requestBody)
+	if err != nil {
+		return accountHistory{}, err
+	}
+	var history accountHistory
+	if err = json.Unmarshal(responseBytes, &history); err != nil {
+		return accountHistory{}, err
+	}
+	// Need to check history.Error because of
+	// https://github.com/nanocurrency/nano-node/issues/1782.
+	if history.Error == "Account not found" {
+		return accountHistory{}, ErrAccountNotFound
+	} else if history.Error != "" {
+		return accountHistory{}, fmt.Errorf("could not fetch account history: %s", history.Error)
+	}
+	return history, nil
+}
+
+func (a Account) toHistoryEntries(blocks []accountHistoryBlock) ([]HistoryEntry, error) {
+	entries := make([]HistoryEntry, len(blocks))
+	for i, block := range blocks {
+		var err error
+		if entries[i], err = a.toHistoryEntry(block); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// toHistoryEntry verifies the hash and signature of block and converts
+// it to a HistoryEntry.
+func (a Account) toHistoryEntry(block accountHistoryBlock) (HistoryEntry, error) {
+	entry := HistoryEntry{
+		Hash:      block.Hash,
+		Amount:    block.Amount,
+		Confirmed: block.Confirmed == "true",
+	}
+	var err error
+	if entry.Height, err = strconv.ParseUint(block.Height, 10, 64); err != nil {
+		return entry, fmt.Errorf("cannot parse '%s' as height: %v", block.Height, err)
+	}
+	switch b := block.Block.(type) {
+	case Block:
+		b.Account = a.Address
+		if err = fillStateHistoryEntry(&entry, b, block.SubTypeName); err != nil {
+			return entry, err
+		}
+	case LegacyBlock:
+		if err = fillLegacyHistoryEntry(&entry, b); err != nil {
+			return entry, err
+		}
+	}
+	hash, err := entry.Block.Hash()
+	if err != nil {
+		return entry, err
+	}
+	if hash != entry.Hash {
+		return entry, &ChainError{entry.Hash, entry.Height, "hash does not match contents"}
+	}
+	if valid, err := entry.Block.VerifySignature(a); err != nil {
+		return entry, err
+	} else if !valid {
+		return entry, &ChainError{entry.Hash, entry.Height, "invalid signature"}
+	}
+	timestamp, err := strconv.ParseInt(block.LocalTimestamp, 10, 64)
+	if err != nil {
+		return entry, fmt.Errorf("cannot parse '%s' as timestamp: %v", block.LocalTimestamp, err)
+	}
+	entry.Timestamp = time.Unix(timestamp, 0)
+	return entry, nil
+}
+
+func fillStateHistoryEntry(entry *HistoryEntry, block Block, subType string) error {
+	switch subType {
+	case "send":
+		entry.SubType = SubTypeSend
+	case "receive", "open":
+		entry.SubType = SubTypeReceive
+	case "change":
+		entry.SubType = SubTypeChange
+	case "epoch":
+		entry.SubType = SubTypeEpoch
+	default:
+		return fmt.Errorf("block subtype '%s' is not supported", subType)
+	}
+	block.SubType = entry.SubType
+	entry.Block = block
+	entry.Balance = block.Balance
+	entry.BalanceKnown = true
+	switch entry.SubType {
+	case SubTypeSend:
+		link, ok := big.NewInt(0).SetString(block.Link, 16)
+		if !ok {
+			return fmt.Errorf("cannot parse '%s' as an integer", block.Link)
+		}
+		var err error
+		if entry.Counterparty, err = getAddress(link); err != nil {
+			return err
+		}
+	case SubTypeReceive:
+		entry.source = block.Link
+	case SubTypeChange:
+		entry.Amount = Amount{}
+		entry.Counterparty = block.Representative
+	case SubTypeEpoch:
+		entry.Amount = Amount{}
+	}
+	return nil
+}
+
+func fillLegacyHistoryEntry(entry *HistoryEntry, block LegacyBlock) error {
+	entry.Block = block
+	switch block.Type {
+	case "send":
+		entry.SubType = SubTypeSend
+		entry.Counterparty = block.Destination
+		var err error
+		if entry.Balance, err = block.DecodeBalance(); err != nil {
+			return err
+		}
+		entry.BalanceKnown = true
+	case "receive", "open":
+		entry.SubType = SubTypeReceive
+		entry.source = block.Source
+	case "change":
+		entry.SubType = SubTypeChange
+		entry.Amount = Amount{}
+		entry.Counterparty = block.Representative
+	}
+	return nil
+}
+
+// verifyHistoryAmounts ensures that consecutive entries are linked and
+// that their amounts match the difference of their balances. The amount
+// of the oldest entry can only be verified, if it opened the account.
+//
+// Missing balances of legacy blocks are derived on the way.
+func verifyHistoryAmounts(entries []HistoryEntry) error {
+	for i := range entries {
+		entry := &entries[i]
+		var previous *HistoryEntry
+		if i+1 < len(entries) {
+			previous = &entries[i+1]
+			if previous.Hash != entry.Block.PreviousHash() {
+				return &ChainError{entry.Hash, entry.Height, "previous block does not match"}
+			}
+			if previous.Height+1 != entry.Height {
+				return &ChainError{entry.Hash, entry.Height, "height is not consecutive"}
+			}
+		} else if entry.Height != 1 {
+			continue
+		} else if entry.Block.PreviousHash() != strings.Repeat("0", 64) {
+			return &ChainError{entry.Hash, entry.Height, "first block has a predecessor"}
+		}
+		if !entry.BalanceKnown {
+			continue // Cannot be verified without a newer state block.
+		}
+		previousBalance, err := balanceBefore(*entry)
+		if err != nil {
+			return err
+		}
+		if previous == nil {
+			if previousBalance.Sign() != 0 {
+				return &ChainError{entry.Hash, entry.Height, "balance change does not match amount"}
+			}
+		} else if !previous.BalanceKnown {
+			previous.Balance = previousBalance
+			previous.BalanceKnown = true
+		} else if previous.Balance.Cmp(previousBalance) != 0 {
+			return &ChainError{entry.Hash, entry.Height, "balance change does not match amount"}
+		}
+	}
+	return nil
+}
+
+// balanceBefore calculates the balance before entry from its balance
+// and amount.
+func balanceBefore(entry HistoryEntry) (Amount, error) {
+	var ok bool
+	balance := entry.Balance
+	switch entry.SubType {
+	case SubTypeSend:
+		ok = entry.Amount.Sign() > 0
+		balance = balance.Add(entry.Amount)
+	case SubTypeReceive:
+		ok = entry.Amount.Sign() > 0
+		balance = balance.Sub(entry.Amount)
+	case SubTypeChange, SubTypeEpoch:
+		ok = entry.Amount.IsZero()
+	}
+	if !ok || balance.Sign() < 0 {
+		return Amount{}, &ChainError{entry.Hash, entry.Height, "balance change does not match amount"}
+	}
+	return balance, nil
+}
+
+// fillReceiveCounterparties sets the Counterparty of all receive
+// entries of a to the account of their source block. The hash and
+// signature of each source block are verified, as well as that it sends
+// to a.
+func (c *Client) fillReceiveCounterparties(ctx context.Context, a Account, entries []HistoryEntry) error {
+	sources := make([]string, 0)
+	for _, entry := range entries {
+		if entry.SubType == SubTypeReceive {
+			sources = append(sources, entry.source)
+		}
+	}
+	if len(sources) == 0 {
+		return nil
+	}
+	info, err := c.fetchBlocksInfo(ctx, sources)
+	if err != nil {
+		return err
+	}
+	for i, entry := range entries {
+		if entry.SubType != SubTypeReceive {
+			continue
+		}
+		item, ok := info.Blocks[entry.source]
+		if !ok {
+			return &ChainError{entry.Hash, entry.Height, "source block is missing"}
+		}
+		source := Receivable{Hash: entry.source, Source: item.BlockAccount}
+		if err = a.verifySend(item.Contents, source); err == ErrAccountManipulated {
+			return &ChainError{entry.Hash, entry.Height, "source block is not a send to this account"}
+		} else if err != nil {
+			return err
+		}
+		entries[i].Counterparty = item.BlockAccount
+	}
+	return nil
+}
+
+// fetchBlocksInfo fetches the blocks with the given hashes, split into
+// batches of at most c.BatchSize hashes. This is the only place, where
+// the blocks_info RPC is used.
+func (c *Client) fetchBlocksInfo(ctx context.Context, hashes []string) (blocksInfo, error) {
+	info := blocksInfo{Blocks: make(map[string]blocksInfoItem, len(hashes))}
+	err := c.forEachBatch(len(hashes), func(start, end int) error {
+		request := struct {
+			Action    string   `json:"action"`
+			JsonBlock string   `json:"json_block"`
+			Hashes    []string `json:"hashes"`
+		}{"blocks_info", "true", hashes[start:end]}
+		var response blocksInfo
+		if err := c.doBatchRPC(ctx, request, &response); err != nil {
+			return err
+		}
+		// Need to check response.Error because of
+		// https://github.com/nanocurrency/nano-node/issues/1782.
+		if response.Error != "" {
+			return fmt.Errorf("could not fetch blocks info: %s", response.Error)
+		}
+		for hash, item := range response.Blocks {
+			info.Blocks[hash] = item
+		}
+		return nil
+	})
+	if err != nil {
+		return blocksInfo{}, err
+	}
+	return info, nil
+}
@@ -0,0 +1,287 @@
+package atto
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// historyFixture has the layout of an account_history response with
+// "raw" set to "true": "account" is the counterparty, legacy open
+// blocks name their own account in "opened" and legacy send blocks
+// have a decimal balance. The chain of nano_3cyb3r… has three legacy
+// blocks and two state blocks on top of its legacy open block; all
+// received blocks were sent by nano_1o3igd….
+const historyFixture = `{
+	"account": "nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh",
+	"history": [
+		{
+			"type": "state",
+			"representative": "nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh",
+			"link": "0000000000000000000000000000000000000000000000000000000000000000",
+			"balance": "6000000000000000000000000000000",
+			"previous": "BF07558AF5297D5812DA3C36A0CE394B85783F047D8FEFE873F648897B39A0D0",
+			"subtype": "change",
+			"local_timestamp": "1700000600",
+			"height": "6",
+			"hash": "55A278B239C3CBEF5C9A275CF774A74815E0C206526D65A631974F95666E937C",
+			"confirmed": "true",
+			"work": "a7d3b6f1e4c2a0d9",
+			"signature": "B81EBF6DBF3722876E9C7E5DDBE192287501E05F585E231217C4EBA5DDB970BFBCD9F33F9160C8192EC3275999D299A38634AD92E41F06CC6305CBFD2386F504"
+		},
+		{
+			"type": "state",
+			"representative": "nano_1o3igdpf8c4msdgwcop71x4o16zzkhe4kyku4axdi8iwh8wh13e4fwgherik",
+			"link": "543072ECD32853CADDC556C507455013FF93D8297A5B123AB81A1C79B8F00582",
+			"balance": "6000000000000000000000000000000",
+			"previous": "DAD9AEBB946211CD8F181C391A7C3DC556BAB8FCF69E86FBC67CF6DA813D9A39",
+			"subtype": "send",
+			"account": "nano_1o3igdpf8c4msdgwcop71x4o16zzkhe4kyku4axdi8iwh8wh13e4fwgherik",
+			"amount": "1000000000000000000000000000000",
+			"local_timestamp": "1700000500",
+			"height": "5",
+			"hash": "BF07558AF5297D5812DA3C36A0CE394B85783F047D8FEFE873F648897B39A0D0",
+			"confirmed": "true",
+			"work": "5e8c1d2b9a4f7e60",
+			"signature": "C962DEC8F8D82DFA09FEAD4E2E9EE78DB779AFFA736F7C2755166E4CDF4E91940056F863133968C2DB92FD5C441B227F94775E42CC6C64EA65B8B1CA14CFD905"
+		},
+		{
+			"type": "change",
+			"representative": "nano_1o3igdpf8c4msdgwcop71x4o16zzkhe4kyku4axdi8iwh8wh13e4fwgherik",
+			"previous": "AE9F9063787DE0DDCD5824EC62281B914BCF5C486B2D33581EADF7C8C6E0E619",
+			"local_timestamp": "1700000400",
+			"height": "4",
+			"hash": "DAD9AEBB946211CD8F181C391A7C3DC556BAB8FCF69E86FBC67CF6DA813D9A39",
+			"confirmed": "true",
+			"work": "0b7e2f94c61d83a5",
+			"signature": "2B917E7214CC3E08F74FCDEEBE5727A534E595C740E72CCFFB92875D9434D50ED1A809755DEF61F8C2DA7D6AAB6EDD88C51D0882C5406BF029D0BB53C7BB990A"
+		},
+		{
+			"type": "receive",
+			"account": "nano_1o3igdpf8c4msdgwcop71x4o16zzkhe4kyku4axdi8iwh8wh13e4fwgherik",
+			"amount": "4000000000000000000000000000000",
+			"source": "DA14C21178EC641EDE7B659627CE11B22F6DE568D84A22AEF25D4488E095AC9C",
+			"previous": "970C713374D47E4ECE819FA748FF6B540AC123E4BCC05060C297FC7A973E1EAB",
+			"local_timestamp": "1700000300",
+			"height": "3",
+			"hash": "AE9F9063787DE0DDCD5824EC62281B914BCF5C486B2D33581EADF7C8C6E0E619",
+			"confirmed": "true",
+			"work": "d41f0c7a3e95b628",
+			"signature": "5173FB379A59DB443CA9DC9B68D2C91792291F84B9000DC3B79E3011C0F0A5385F17BE35AA992F09814E669BD4A419B55C06D35D2A52E14F31DB1512C7F04C0A"
+		},
+		{
+			"type": "send",
+			"account": "nano_1o3igdpf8c4msdgwcop71x4o16zzkhe4kyku4axdi8iwh8wh13e4fwgherik",
+			"amount": "2000000000000000000000000000000",
+			"destination": "nano_1o3igdpf8c4msdgwcop71x4o16zzkhe4kyku4axdi8iwh8wh13e4fwgherik",
+			"balance": "3000000000000000000000000000000",
+			"previous": "4382AB186BFB5D07F0B06DD8C25D972A310BB68322E01070797501C06C8E9ECC",
+			"local_timestamp": "1700000200",
+			"height": "2",
+			"hash": "970C713374D47E4ECE819FA748FF6B540AC123E4BCC05060C297FC7A973E1EAB",
+			"confirmed": "true",
+			"work": "92c6e05b1f7d3a48",
+			"signature": "F2409971D08B7E87FD5CCBBF79B15BFB9E79388D1430094A3026A2597916D028F0F2228B8084544F0E1102D5AA17DD981366A799553D0325C613383F9387C90F"
+		},
+		{
+			"type": "open",
+			"representative": "nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh",
+			"source": "A7A2DA58E3A9958D9E8485514A15222D2C93C70DF2CB7AEE572D2AFB70440837",
+			"opened": "nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh",
+			"account": "nano_1o3igdpf8c4msdgwcop71x4o16zzkhe4kyku4axdi8iwh8wh13e4fwgherik",
+			"amount": "5000000000000000000000000000000",
+			"local_timestamp": "1700000100",
+			"height": "1",
+			"hash": "4382AB186BFB5D07F0B06DD8C25D972A310BB68322E01070797501C06C8E9ECC",
+			"confirmed": "true",
+			"work": "3fa9d7c02e6b5184",
+			"signature": "6A7A7EF4742B5C91FC81C7D39A2319D55FE03060A256D1F93894CA79C3F09CDADD0BF55B75AABC4DA3DAC33BAA0683EE08C41962C28536AA9FAB2B338B3B9B07"
+		}
+	]
+}`
+
+// historySources are the blocks_info contents of the blocks received
+// in historyFixture. Unlike in account histories, the balance of
+// legacy send blocks is hexadecimal here.
+var historySources = map[string]string{
+	"A7A2DA58E3A9958D9E8485514A15222D2C93C70DF2CB7AEE572D2AFB70440837": `{
+		"type": "send",
+		"previous": "4270F4FB3A820FE81827065F967A9589DF5CA860443F812D21ECE964AC359E05",
+		"destination": "nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh",
+		"balance": "000004AF118E314A256449EDC0000000",
+		"work": "6c1e9b3d08f2a75e",
+		"signature": "5D663BB97AD4D836CD567E230E93FE62813C6665834F997FA1A94694AFF6699CDDE3B58BBA0651B12149D3CA132345377BEB9F033C4FBD42A928897E5E36A30A"
+	}`,
+	"DA14C21178EC641EDE7B659627CE11B22F6DE568D84A22AEF25D4488E095AC9C": `{
+		"type": "state",
+		"account": "nano_1o3igdpf8c4msdgwcop71x4o16zzkhe4kyku4axdi8iwh8wh13e4fwgherik",
+		"previous": "A7A2DA58E3A9958D9E8485514A15222D2C93C70DF2CB7AEE572D2AFB70440837",
+		"representative": "nano_1o3igdpf8c4msdgwcop71x4o16zzkhe4kyku4axdi8iwh8wh13e4fwgherik",
+		"balance": "91000000000000000000000000000000",
+		"link": "ABC90E3961A5022E8715FE63A951662F76B65DFE1AAA9500BBE85D55DC8B6904",
+		"link_as_account": "nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh",
+		"work": "e27a4c90b5d83f16",
+		"signature": "A1BF70E1E5FF208F8BBBC30EA0ED395CE19378C6A4590886902E87E9A3D8E9F905EEDEF1FA197B76EDD917E4FFD7EC1053CD31DAB5CA66A2DA7490A10C67CB0E"
+	}`,
+}
+
+// historyFrontier is the frontier of historyFixture as returned by
+// block_info.
+const historyFrontier = `{
+	"type": "state",
+	"account": "nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh",
+	"previous": "BF07558AF5297D5812DA3C36A0CE394B85783F047D8FEFE873F648897B39A0D0",
+	"representative": "nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh",
+	"balance": "6000000000000000000000000000000",
+	"link": "0000000000000000000000000000000000000000000000000000000000000000",
+	"work": "a7d3b6f1e4c2a0d9",
+	"signature": "B81EBF6DBF3722876E9C7E5DDBE192287501E05F585E231217C4EBA5DDB970BFBCD9F33F9160C8192EC3275999D299A38634AD92E41F06CC6305CBFD2386F504"
+}`
+
+// historyNode serves history, which has the layout of historyFixture,
+// the account info of its account and the blocks of historySources.
+func historyNode(t *testing.T, history string) *httptest.Server {
+	var fixture struct {
+		History []json.RawMessage `json:"history"`
+	}
+	if err := json.Unmarshal([]byte(history), &fixture); err != nil {
+		t.Fatal(err)
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Action string   `json:"action"`
+			Head   string   `json:"head"`
+			Count  int      `json:"count,string"`
+			Hashes []string `json:"hashes"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		switch request.Action {
+		case "account_info":
+			fmt.Fprint(w, `{
+				"frontier": "55A278B239C3CBEF5C9A275CF774A74815E0C206526D65A631974F95666E937C",
+				"representative": "nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh",
+				"balance": "6000000000000000000000000000000"
+			}`)
+		case "block_info":
+			fmt.Fprintf(w, `{"contents": %s}`, historyFrontier)
+		case "account_history":
+			entries := fixture.History
+			for i, entry := range entries {
+				var meta struct {
+					Hash string `json:"hash"`
+				}
+				json.Unmarshal(entry, &meta)
+				if meta.Hash == request.Head {
+					entries = entries[i:]
+					break
+				}
+			}
+			previous := ""
+			if len(entries) > request.Count {
+				var meta struct {
+					Previous string `json:"previous"`
+				}
+				json.Unmarshal(entries[request.Count-1], &meta)
+				previous = meta.Previous
+				entries = entries[:request.Count]
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"history": entries, "previous": previous})
+		case "blocks_info":
+			blocks := make(map[string]interface{})
+			for _, hash := range request.Hashes {
+				blocks[hash] = map[string]interface{}{
+					"block_account": "nano_1o3igdpf8c4msdgwcop71x4o16zzkhe4kyku4axdi8iwh8wh13e4fwgherik",
+					"contents":      json.RawMessage(historySources[hash]),
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"blocks": blocks})
+		}
+	}))
+}
+
+func TestFetchHistory(t *testing.T) {
+	account, err := NewAccountFromAddress("nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := historyNode(t, historyFixture)
+	defer server.Close()
+	client := NewClient(server.URL)
+	history, err := client.FetchHistory(account, HistoryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const sender = "nano_1o3igdpf8c4msdgwcop71x4o16zzkhe4kyku4axdi8iwh8wh13e4fwgherik"
+	expected := []struct {
+		subType      BlockSubType
+		balance      string
+		counterparty string
+	}{
+		{SubTypeChange, "6", account.Address},
+		{SubTypeSend, "6", sender},
+		{SubTypeChange, "7", sender},
+		{SubTypeReceive, "7", sender},
+		{SubTypeSend, "3", sender},
+		{SubTypeReceive, "5", sender},
+	}
+	if len(history.Entries) != len(expected) || history.Previous != "" {
+		t.Fatalf("expected %d entries without previous, got %+v", len(expected), history)
+	}
+	for i, entry := range history.Entries {
+		e := expected[i]
+		if entry.SubType != e.subType || !entry.BalanceKnown || entry.Balance.Text(Mnano) != e.balance ||
+			entry.Counterparty != e.counterparty {
+			t.Errorf("unexpected entry at height %d: %+v", entry.Height, entry)
+		}
+	}
+	if block, ok := history.Entries[0].Block.(Block); !ok || block.Account != account.Address {
+		t.Errorf("expected state block of %s, got %+v", account.Address, history.Entries[0].Block)
+	}
+
+	// The amount of the oldest entry of a page is verified, too.
+	options := HistoryOptions{Count: 1, Head: "970C713374D47E4ECE819FA748FF6B540AC123E4BCC05060C297FC7A973E1EAB"}
+	history, err = client.FetchHistory(account, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history.Entries) != 1 || history.Previous != "4382AB186BFB5D07F0B06DD8C25D972A310BB68322E01070797501C06C8E9ECC" {
+		t.Errorf("unexpected page %+v", history)
+	}
+	tampered := strings.Replace(historyFixture, `"amount": "2000000000000000000000000000000"`,
+		`"amount": "3000000000000000000000000000000"`, 1)
+	tamperedServer := historyNode(t, tampered)
+	defer tamperedServer.Close()
+	_, err = NewClient(tamperedServer.URL).FetchHistory(account, options)
+	if !errors.Is(err, ErrAccountManipulated) {
+		t.Errorf("expected %v, got %v", ErrAccountManipulated, err)
+	}
+}
+
+func TestFillReceiveCounterparties(t *testing.T) {
+	server := historyNode(t, historyFixture)
+	defer server.Close()
+	client := NewClient(server.URL)
+	receive := HistoryEntry{SubType: SubTypeReceive, source: "DA14C21178EC641EDE7B659627CE11B22F6DE568D84A22AEF25D4488E095AC9C"}
+	account, err := NewAccountFromAddress("nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = client.fillReceiveCounterparties(context.Background(), account, []HistoryEntry{receive}); err != nil {
+		t.Errorf("expected valid source, got %v", err)
+	}
+
+	// The source block sends to another account.
+	other, err := NewAccountFromAddress("nano_3t6k35gi95xu6tergt6p69ck76ogmitsa8mnijtpxm9fkcm736xtoncuohr3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.fillReceiveCounterparties(context.Background(), other, []HistoryEntry{receive})
+	if !errors.Is(err, ErrAccountManipulated) {
+		t.Errorf("expected %v, got %v", ErrAccountManipulated, err)
+	}
+}
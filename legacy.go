@@ -0,0 +1,242 @@
+package atto
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// AnyBlock is implemented by Block and LegacyBlock, so that state
+// blocks and legacy blocks can be handled uniformly.
+type AnyBlock interface {
+	// Hash calculates the block's hash and returns it's string
+	// representation.
+	Hash() (string, error)
+
+	// BlockType returns the type of the block, which is "state" for
+	// state blocks and "open", "send", "receive" or "change" for
+	// legacy blocks.
+	BlockType() string
+
+	// PreviousHash returns the hash of the preceding block in the
+	// account's chain or 64 zeros if this is the first block.
+	PreviousHash() string
+
+	// VerifySignature reports whether the block has been signed by the
+	// owner of account.
+	VerifySignature(account Account) (bool, error)
+}
+
+// LegacyBlock represents one of the block types that were used before
+// state blocks were introduced. They can still be found in the history
+// of old accounts, but can no longer be created.
+//
+// Which fields are used depends on Type:
+//   - "open": Source, Representative and Account
+//   - "send": Previous, Destination and Balance
+//   - "receive": Previous and Source
+//   - "change": Previous and Representative
+type LegacyBlock struct {
+	Type           string `json:"type"`
+	Previous       string `json:"previous,omitempty"`
+	Destination    string `json:"destination,omitempty"`
+	Source         string `json:"source,omitempty"`
+	Representative string `json:"representative,omitempty"`
+	Account        string `json:"account,omitempty"`
+
+	// Balance is hexadecimal encoded, unlike the Balance of state
+	// blocks.
+	Balance string `json:"balance,omitempty"`
+
+	Signature string `json:"signature"`
+	Work      string `json:"work"`
+}
+
+// ParseBlock parses the JSON representation of a block, as used by the
+// node. The result is a Block for state blocks or a LegacyBlock for
+// legacy blocks.
+func ParseBlock(in []byte) (AnyBlock, error) {
+	var header struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(in, &header); err != nil {
+		return nil, err
+	}
+	switch header.Type {
+	case "state":
+		var block Block
+		err := json.Unmarshal(in, &block)
+		return block, err
+	case "open", "send", "receive", "change":
+		var block LegacyBlock
+		err := json.Unmarshal(in, &block)
+		return block, err
+	}
+	return nil, fmt.Errorf("unknown block type '%s'", header.Type)
+}
+
+// BlockType returns b.Type.
+func (b Block) BlockType() string {
+	return b.Type
+}
+
+// PreviousHash returns b.Previous.
+func (b Block) PreviousHash() string {
+	return b.Previous
+}
+
+// VerifySignature reports whether b has been signed by the owner of
+// account or, for epoch blocks, by the epoch signer.
+func (b Block) VerifySignature(account Account) (bool, error) {
+	err := b.verifySignature(account)
+	if err == errInvalidSignature {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// BlockType returns b.Type.
+func (b LegacyBlock) BlockType() string {
+	return b.Type
+}
+
+// PreviousHash returns b.Previous or 64 zeros for open blocks.
+func (b LegacyBlock) PreviousHash() string {
+	if b.Type == "open" {
+		return strings.Repeat("0", 64)
+	}
+	return b.Previous
+}
+
+// VerifySignature reports whether b has been signed by the owner of
+// account.
+func (b LegacyBlock) VerifySignature(account Account) (bool, error) {
+	sig, ok := big.NewInt(0).SetString(b.Signature, 16)
+	if !ok {
+		return false, fmt.Errorf("cannot parse '%s' as an integer", b.Signature)
+	}
+	hash, err := b.hashBytes()
+	if err != nil {
+		return false, err
+	}
+	return isValidSignature(account.PublicKey, hash, bigIntToBytes(sig, 64)), nil
+}
+
+// DecodeBalance decodes the hexadecimal balance of a send block. Other
+// legacy blocks do not contain their balance.
+func (b LegacyBlock) DecodeBalance() (Amount, error) {
+	if b.Type != "send" {
+		return Amount{}, fmt.Errorf("%s blocks do not contain a balance", b.Type)
+	}
+	balance, ok := big.NewInt(0).SetString(b.Balance, 16)
+	if !ok {
+		return Amount{}, fmt.Errorf("%w: cannot parse '%s'", ErrInvalidAmount, b.Balance)
+	}
+	amount := Amount{balance}
+	return amount, amount.validate()
+}
+
+// Hash calculates the block's hash and returns it's string
+// representation.
+func (b LegacyBlock) Hash() (string, error) {
+	hashBytes, err := b.hashBytes()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%064X", hashBytes), nil
+}
+
+func (b LegacyBlock) hashBytes() ([]byte, error) {
+	// Legacy blocks have no preamble; their fields are simply
+	// concatenated.
+	var msg []byte
+	switch b.Type {
+	case "open":
+		source, err := decodeHash(b.Source)
+		if err != nil {
+			return nil, err
+		}
+		representative, err := getPublicKeyFromAddress(b.Representative)
+		if err != nil {
+			return nil, err
+		}
+		account, err := getPublicKeyFromAddress(b.Account)
+		if err != nil {
+			return nil, err
+		}
+		msg = append(msg, source...)
+		msg = append(msg, bigIntToBytes(representative, 32)...)
+		msg = append(msg, bigIntToBytes(account, 32)...)
+	case "send":
+		previous, err := decodeHash(b.Previous)
+		if err != nil {
+			return nil, err
+		}
+		destination, err := getPublicKeyFromAddress(b.Destination)
+		if err != nil {
+			return nil, err
+		}
+		balance, ok := big.NewInt(0).SetString(b.Balance, 16)
+		if !ok || balance.BitLen() > 128 {
+			return nil, fmt.Errorf("cannot parse '%s' as a balance", b.Balance)
+		}
+		msg = append(msg, previous...)
+		msg = append(msg, bigIntToBytes(destination, 32)...)
+		msg = append(msg, bigIntToBytes(balance, 16)...)
+	case "receive":
+		previous, err := decodeHash(b.Previous)
+		if err != nil {
+			return nil, err
+		}
+		source, err := decodeHash(b.Source)
+		if err != nil {
+			return nil, err
+		}
+		msg = append(msg, previous...)
+		msg = append(msg, source...)
+	case "change":
+		previous, err := decodeHash(b.Previous)
+		if err != nil {
+			return nil, err
+		}
+		representative, err := getPublicKeyFromAddress(b.Representative)
+		if err != nil {
+			return nil, err
+		}
+		msg = append(msg, previous...)
+		msg = append(msg, bigIntToBytes(representative, 32)...)
+	default:
+		return nil, fmt.Errorf("unknown block type '%s'", b.Type)
+	}
+	hash := blake2b.Sum256(msg)
+	return hash[:], nil
+}
+
+// blockBalance returns the balance contained in block. Of the legacy
+// blocks, only send blocks contain their balance; ok is false for the
+// others.
+func blockBalance(block AnyBlock) (balance Amount, ok bool, err error) {
+	switch b := block.(type) {
+	case Block:
+		return b.Balance, true, nil
+	case LegacyBlock:
+		if b.Type == "send" {
+			balance, err = b.DecodeBalance()
+			return balance, err == nil, err
+		}
+	}
+	return Amount{}, false, nil
+}
+
+// decodeHash decodes a hexadecimal, 32 byte hash.
+func decodeHash(in string) ([]byte, error) {
+	out, err := hex.DecodeString(in)
+	if err == nil && len(out) != 32 {
+		err = fmt.Errorf("'%s' is not a 32 byte hash", in)
+	}
+	return out, err
+}
@@ -0,0 +1,182 @@
+package atto
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const legacyGenesisBlock = `{
+		"type": "open",
+		"source": "E89208DD038FBB269987689621D52292AE9C35941A7484756ECCED92A65093BA",
+		"representative": "xrb_3t6k35gi95xu6tergt6p69ck76ogmitsa8mnijtpxm9fkcm736xtoncuohr3",
+		"account": "xrb_3t6k35gi95xu6tergt6p69ck76ogmitsa8mnijtpxm9fkcm736xtoncuohr3",
+		"work": "62f05417dd3fb691",
+		"signature": "9F0C933C8ADE004D808EA1985FA746A7E95BA2A38F867640F53EC8F180BDFE9E2C1268DEAD7C2664F356E37ABA362BC58E46DBA03E523A7B5A19E4B6EB12BB02"
+	}`
+
+func TestLegacyGenesisBlock(t *testing.T) {
+	block, err := ParseBlock([]byte(legacyGenesisBlock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := block.(LegacyBlock); !ok {
+		t.Fatalf("expected LegacyBlock, got %T", block)
+	}
+	hash, err := block.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "991CF190094C00F0B68E2E5F75F6BEE95A2E0BD93CEAA4A6734DB9F19B728948"
+	if hash != expected {
+		t.Errorf("expected hash %s, got %s", expected, hash)
+	}
+	account, err := NewAccountFromAddress("nano_3t6k35gi95xu6tergt6p69ck76ogmitsa8mnijtpxm9fkcm736xtoncuohr3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if valid, err := block.VerifySignature(account); err != nil || !valid {
+		t.Errorf("expected valid signature, got %v, %v", valid, err)
+	}
+}
+
+// legacyFrontierNode serves account_info and block_info for an account,
+// whose frontier is the legacy block contents.
+func legacyFrontierNode(frontier, representative, balance string, contents []byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Action string `json:"action"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		switch request.Action {
+		case "account_info":
+			fmt.Fprintf(w, `{"frontier": "%s", "representative": "%s", "balance": "%s"}`,
+				frontier, representative, balance)
+		case "block_info":
+			fmt.Fprintf(w, `{"contents": %s}`, contents)
+		}
+	}))
+}
+
+func TestFetchAccountInfoLegacyFrontier(t *testing.T) {
+	genesis, err := NewAccountFromAddress("nano_3t6k35gi95xu6tergt6p69ck76ogmitsa8mnijtpxm9fkcm736xtoncuohr3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const genesisHash = "991CF190094C00F0B68E2E5F75F6BEE95A2E0BD93CEAA4A6734DB9F19B728948"
+	server := legacyFrontierNode(genesisHash, genesis.Address, "1000", []byte(legacyGenesisBlock))
+	defer server.Close()
+	info, err := NewClient(server.URL).FetchAccountInfo(genesis)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Frontier != genesisHash || info.Balance.String() != "1000" {
+		t.Errorf("unexpected account info: %+v", info)
+	}
+
+	// The representative of open blocks is verified.
+	server = legacyFrontierNode(genesisHash, "nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh", "1000", []byte(legacyGenesisBlock))
+	defer server.Close()
+	if _, err = NewClient(server.URL).FetchAccountInfo(genesis); err != ErrAccountManipulated {
+		t.Errorf("expected %v, got %v", ErrAccountManipulated, err)
+	}
+
+	// The balance of send blocks is verified.
+	privateKey, account := testAccount(t)
+	send, sendHash := newLegacySend(t, privateKey, genesisHash, genesis.Address, 1000)
+	contents, err := json.Marshal(send)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for balance, expected := range map[string]error{"1000": nil, "999": ErrAccountManipulated} {
+		server = legacyFrontierNode(sendHash, genesis.Address, balance, contents)
+		defer server.Close()
+		if _, err = NewClient(server.URL).FetchAccountInfo(account); err != expected {
+			t.Errorf("expected %v for balance %s, got %v", expected, balance, err)
+		}
+	}
+}
+
+func testAccount(t *testing.T) (*big.Int, Account) {
+	privateKey, err := NewPrivateKey("D420296F5FEF486175FAA8F649DED00A5B0A096DB8D03972937542C51A7F296C", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := NewAccount(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return privateKey, account
+}
+
+// newLegacySend creates a legacy send block, that is signed with
+// privateKey, and returns it with its hash.
+func newLegacySend(t *testing.T, privateKey *big.Int, previous, destination string, balance int64) (LegacyBlock, string) {
+	send := LegacyBlock{
+		Type:        "send",
+		Previous:    previous,
+		Destination: destination,
+		Balance:     fmt.Sprintf("%032X", balance),
+	}
+	hashBytes, err := send.hashBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := sign(derivePublicKey(privateKey), privateKey, hashBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	send.Signature = fmt.Sprintf("%0128X", signature)
+	hash, err := send.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return send, hash
+}
+
+func TestVerifyLegacyReceivable(t *testing.T) {
+	privateKey, sender := testAccount(t)
+	receiver, err := NewAccountFromAddress("nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const genesisHash = "991CF190094C00F0B68E2E5F75F6BEE95A2E0BD93CEAA4A6734DB9F19B728948"
+	first, firstHash := newLegacySend(t, privateKey, genesisHash, receiver.Address, 5)
+	second, secondHash := newLegacySend(t, privateKey, firstHash, receiver.Address, 3)
+	blocks := map[string]interface{}{
+		genesisHash: json.RawMessage(legacyGenesisBlock),
+		firstHash:   first,
+		secondHash:  second,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Hashes []string `json:"hashes"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		items := make(map[string]interface{})
+		for _, hash := range request.Hashes {
+			items[hash] = map[string]interface{}{"contents": blocks[hash]}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"blocks": items})
+	}))
+	defer server.Close()
+
+	receivable := Receivable{Hash: secondHash, Amount: NewAmount(big.NewInt(2)), Source: sender.Address}
+	if err = receiver.VerifyReceivable(server.URL, receivable); err != nil {
+		t.Errorf("expected valid receivable, got %v", err)
+	}
+	receivable.Amount = NewAmount(big.NewInt(3))
+	if err = receiver.VerifyReceivable(server.URL, receivable); err != ErrAccountManipulated {
+		t.Errorf("expected %v, got %v", ErrAccountManipulated, err)
+	}
+
+	// The balance before the first send is not known.
+	receivable = Receivable{Hash: firstHash, Amount: NewAmount(big.NewInt(1)), Source: sender.Address}
+	if err = receiver.VerifyReceivable(server.URL, receivable); !errors.Is(err, ErrUnverifiableReceivable) {
+		t.Errorf("expected %v, got %v", ErrUnverifiableReceivable, err)
+	}
+}
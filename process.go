@@ -1,10 +1,67 @@
 package atto
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
 
+// These errors are used when the node refuses to process a block.
+// They are wrapped by *ProcessError, so errors.Is can be used to
+// detect them. A refusal because of insufficient work is reported with
+// ErrInsufficientWork.
+var (
+	ErrFork                   = fmt.Errorf("fork")
+	ErrOldBlock               = fmt.Errorf("old block")
+	ErrGapPrevious            = fmt.Errorf("gap previous block")
+	ErrGapSource              = fmt.Errorf("gap source block")
+	ErrBadSignature           = fmt.Errorf("bad signature")
+	ErrBalanceMismatch        = fmt.Errorf("balance and amount delta do not match")
+	ErrRepresentativeMismatch = fmt.Errorf("representative mismatch")
+	ErrBlockPosition          = fmt.Errorf("block cannot follow the previous block")
+	ErrNegativeSpend          = fmt.Errorf("negative spend")
+	ErrUnreceivable           = fmt.Errorf("unreceivable")
+)
+
+// processErrors maps the error messages of the process RPC to the
+// corresponding errors. Some messages have changed between node
+// versions, so older messages are kept as well.
+var processErrors = map[string]error{
+	"Fork":                                        ErrFork,
+	"Old block":                                   ErrOldBlock,
+	"Gap previous block":                          ErrGapPrevious,
+	"Gap source block":                            ErrGapSource,
+	"Block work is less than threshold":           ErrInsufficientWork,
+	"Block work is insufficient":                  ErrInsufficientWork,
+	"Bad signature":                               ErrBadSignature,
+	"Balance and amount delta do not match":       ErrBalanceMismatch,
+	"Balance mismatch":                            ErrBalanceMismatch,
+	"Representative mismatch":                     ErrRepresentativeMismatch,
+	"This block cannot follow the previous block": ErrBlockPosition,
+	"Block position":                              ErrBlockPosition,
+	"Negative spend":                              ErrNegativeSpend,
+	"Unreceivable":                                ErrUnreceivable,
+}
+
+// ProcessError is used when the node refuses to process a block.
+type ProcessError struct {
+	// Message is the error message returned by the node.
+	Message string
+
+	// Err is one of the errors above or nil, if the message is
+	// unknown.
+	Err error
+}
+
+func (e *ProcessError) Error() string {
+	return fmt.Sprintf("could not publish block: %s", e.Message)
+}
+
+// Unwrap returns e.Err.
+func (e *ProcessError) Unwrap() error {
+	return e.Err
+}
+
 type process struct {
 	Action    string `json:"action"`
 	JsonBlock string `json:"json_block"`
@@ -16,13 +73,13 @@ type processResponse struct {
 	Error string `json:"error"`
 }
 
-func doProcessRPC(process process, node string) error {
+func doProcessRPC(ctx context.Context, process process, c *Client) error {
 	var requestBody, responseBytes []byte
 	requestBody, err := json.Marshal(process)
 	if err != nil {
 		return err
 	}
-	responseBytes, err = doRPC(string(requestBody), node)
+	responseBytes, err = c.doRPC(ctx, string(requestBody))
 	if err != nil {
 		return err
 	}
@@ -33,7 +90,10 @@ func doProcessRPC(process process, node string) error {
 	// Need to check processResponse.Error because of
 	// https://github.com/nanocurrency/nano-node/issues/1782.
 	if processResponse.Error != "" {
-		err = fmt.Errorf("could not publish block: %s", processResponse.Error)
+		err = &ProcessError{
+			Message: processResponse.Error,
+			Err:     processErrors[processResponse.Error],
+		}
 	}
 	return err
 }
@@ -0,0 +1,46 @@
+package atto
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProcessErrors(t *testing.T) {
+	// These are the exact messages of the node.
+	messages := map[string]error{
+		"Fork":                                        ErrFork,
+		"Old block":                                   ErrOldBlock,
+		"Gap previous block":                          ErrGapPrevious,
+		"Gap source block":                            ErrGapSource,
+		"Block work is less than threshold":           ErrInsufficientWork,
+		"Block work is insufficient":                  ErrInsufficientWork,
+		"Bad signature":                               ErrBadSignature,
+		"Balance and amount delta do not match":       ErrBalanceMismatch,
+		"Balance mismatch":                            ErrBalanceMismatch,
+		"Representative mismatch":                     ErrRepresentativeMismatch,
+		"This block cannot follow the previous block": ErrBlockPosition,
+		"Block position":                              ErrBlockPosition,
+		"Negative spend":                              ErrNegativeSpend,
+		"Unreceivable":                                ErrUnreceivable,
+		"Unknown message":                             nil,
+	}
+	for message, expected := range messages {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"error": "` + message + `"}`))
+		}))
+		err := doProcessRPC(context.Background(), process{}, NewClient(server.URL))
+		server.Close()
+		if expected != nil && !errors.Is(err, expected) {
+			t.Errorf("expected %v, got %v", expected, err)
+		}
+		var processError *ProcessError
+		if !errors.As(err, &processError) || processError.Message != message {
+			t.Errorf("expected *ProcessError with message '%s', got %v", message, err)
+		} else if expected == nil && processError.Err != nil {
+			t.Errorf("expected no known error for '%s', got %v", message, processError.Err)
+		}
+	}
+}
@@ -1,13 +1,21 @@
 package atto
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
 )
 
+// ErrUnverifiableReceivable is used when the amount of a receivable
+// cannot be verified, because the block preceding its send block is a
+// legacy block, that does not contain its balance.
+var ErrUnverifiableReceivable = fmt.Errorf("the amount of the receivable cannot be verified")
+
 // Receivable represents a block that is waiting to be received.
 type Receivable struct {
 	Hash   string
-	Amount string
+	Amount Amount
 	Source string
 }
 
@@ -33,7 +41,7 @@ func (b *receivableBlocks) UnmarshalJSON(in []byte) error {
 }
 
 type receivableBlock struct {
-	Amount string `json:"amount"`
+	Amount Amount `json:"amount"`
 	Source string `json:"source"`
 }
 
@@ -45,3 +53,150 @@ func internalReceivableToReceivable(internalReceivable internalReceivable) []Rec
 	}
 	return receivables
 }
+
+// VerifyReceivable ensures that receivable has not been manipulated by
+// node. The send block and its predecessor are fetched from node. It is
+// verified that the send block's hash and signature are valid, that it
+// belongs to receivable.Source, that its link or, for legacy send
+// blocks, its destination is the public key of Account and that its
+// balance is receivable.Amount lower than the balance of its
+// predecessor.
+//
+// May return ErrAccountManipulated or, if the predecessor is a legacy
+// block without a balance, ErrUnverifiableReceivable.
+func (a Account) VerifyReceivable(node string, receivable Receivable) error {
+	return a.verifyReceivables(context.Background(), nodeClient(node), []Receivable{receivable})
+}
+
+// VerifyReceivableContext is like VerifyReceivable, but aborts when
+// ctx is done.
+func (a Account) VerifyReceivableContext(ctx context.Context, node string, receivable Receivable) error {
+	return a.verifyReceivables(ctx, nodeClient(node), []Receivable{receivable})
+}
+
+// verifyReceivables verifies all receivables of a.
+func (a Account) verifyReceivables(ctx context.Context, c *Client, receivables []Receivable) error {
+	return c.verifyReceivables(ctx, []Account{a}, [][]Receivable{receivables})
+}
+
+// verifyReceivables verifies the receivables of all accounts, where
+// receivables[i] belongs to accounts[i]. Only two chunked blocks_info
+// requests are made, regardless of the number of accounts; one for the
+// send blocks and one for their predecessors.
+func (c *Client) verifyReceivables(ctx context.Context, accounts []Account, receivables [][]Receivable) error {
+	hashes := make([]string, 0)
+	for _, r := range receivables {
+		for _, receivable := range r {
+			hashes = append(hashes, receivable.Hash)
+		}
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+	sends, err := c.fetchBlocksInfo(ctx, hashes)
+	if err != nil {
+		return err
+	}
+	previousHashes := make([]string, 0, len(hashes))
+	for i, account := range accounts {
+		for _, receivable := range receivables[i] {
+			item, ok := sends.Blocks[receivable.Hash]
+			if !ok {
+				return ErrAccountManipulated
+			}
+			if err = account.verifySend(item.Contents, receivable); err != nil {
+				return err
+			}
+			previousHashes = append(previousHashes, item.Contents.PreviousHash())
+		}
+	}
+	previousBlocks, err := c.fetchBlocksInfo(ctx, previousHashes)
+	if err != nil {
+		return err
+	}
+	for _, r := range receivables {
+		for _, receivable := range r {
+			send := sends.Blocks[receivable.Hash].Contents
+			item, ok := previousBlocks.Blocks[send.PreviousHash()]
+			if !ok {
+				return ErrAccountManipulated
+			}
+			if err = verifySendAmount(item.Contents, send, receivable); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// verifySend checks the hash, signature, account and destination of
+// send, which may be a state block or a legacy send block.
+func (a Account) verifySend(send AnyBlock, receivable Receivable) error {
+	sender, err := NewAccountFromAddress(receivable.Source)
+	if err != nil {
+		return err
+	}
+	var destination string
+	switch b := send.(type) {
+	case Block:
+		if !sameAccount(b.Account, receivable.Source) {
+			return ErrAccountManipulated
+		}
+		destination = b.Link
+	case LegacyBlock:
+		if b.Type != "send" {
+			return ErrAccountManipulated
+		}
+		// Legacy blocks do not contain their account; the signature
+		// proves that the block belongs to the source.
+		if destination, err = addressKey(b.Destination); err != nil {
+			return err
+		}
+	}
+	hash, err := send.Hash()
+	if err != nil {
+		return err
+	}
+	if hash != receivable.Hash ||
+		!strings.EqualFold(destination, publicKeyString(a)) ||
+		send.PreviousHash() == strings.Repeat("0", 64) {
+		return ErrAccountManipulated
+	}
+	if valid, err := send.VerifySignature(sender); err != nil {
+		return err
+	} else if !valid {
+		return ErrAccountManipulated
+	}
+	return nil
+}
+
+// verifySendAmount ensures that the balance of send is
+// receivable.Amount lower than the balance of previous. If previous is
+// a legacy block without a balance, ErrUnverifiableReceivable is
+// returned.
+func verifySendAmount(previous, send AnyBlock, receivable Receivable) error {
+	hash, err := previous.Hash()
+	if err != nil {
+		return err
+	}
+	if hash != send.PreviousHash() {
+		return ErrAccountManipulated
+	}
+	previousBalance, ok, err := blockBalance(previous)
+	if err != nil {
+		return err
+	} else if !ok {
+		return ErrUnverifiableReceivable
+	}
+	sendBalance, ok, err := blockBalance(send)
+	if err != nil {
+		return err
+	} else if !ok {
+		return ErrAccountManipulated
+	}
+	amount := receivable.Amount
+	if amount.Sign() < 1 || previousBalance.Sub(sendBalance).Cmp(amount) != 0 {
+		return ErrAccountManipulated
+	}
+	return nil
+}
@@ -3,8 +3,9 @@ package atto
 import "net/http"
 
 // The RequestInterceptor is a function that is used to modify all HTTP
-// requests that are sent to a node. If it is nil, requests are not
-// modified.
+// requests that are sent to a node by the functions taking a node URL.
+// If it is nil, requests are not modified. Requests made through a
+// Client use Client.RequestInterceptor instead.
 //
 // May be used, for example, to authenticate requests.
 var RequestInterceptor func(request *http.Request) error
@@ -3,9 +3,7 @@ package atto
 import (
 	"crypto/rand"
 	"fmt"
-	"io/ioutil"
 	"math/big"
-	"net/http"
 	"strings"
 
 	"golang.org/x/crypto/blake2b"
@@ -106,35 +104,63 @@ func revertBytes(in []byte) []byte {
 	return in
 }
 
-func doRPC(requestBody, node string) (responseBytes []byte, err error) {
-	req, err := http.NewRequest("POST", node, strings.NewReader(requestBody))
-	if err != nil {
-		return
+// ErrInvalidAddress is used when an address is malformed or its
+// checksum does not match. Errors of type *InvalidAddressError wrap it,
+// so errors.Is can be used to detect them.
+var ErrInvalidAddress = fmt.Errorf("invalid address")
+
+// InvalidAddressError describes why an address is invalid.
+type InvalidAddressError struct {
+	Address string
+	Reason  string
+}
+
+func (e *InvalidAddressError) Error() string {
+	return fmt.Sprintf("invalid address '%s': %s", e.Address, e.Reason)
+}
+
+// Unwrap returns ErrInvalidAddress.
+func (e *InvalidAddressError) Unwrap() error {
+	return ErrInvalidAddress
+}
+
+// ValidateAddress checks the prefix, length, alphabet and checksum of
+// address. If address is invalid, an *InvalidAddressError is returned.
+func ValidateAddress(address string) error {
+	_, err := getPublicKeyFromAddress(address)
+	return err
+}
+
+// getPublicKeyFromAddress returns the public key encoded in address. It
+// ensures that address is valid, including its checksum.
+func getPublicKeyFromAddress(address string) (*big.Int, error) {
+	var encoded string
+	if strings.HasPrefix(address, "nano_") {
+		encoded = address[5:]
+	} else if strings.HasPrefix(address, "xrb_") {
+		encoded = address[4:]
+	} else {
+		return nil, &InvalidAddressError{address, "prefix must be 'nano_' or 'xrb_'"}
 	}
-	req.Header.Add("Content-Type", "application/json")
-	if RequestInterceptor != nil {
-		if err = RequestInterceptor(req); err != nil {
-			err = fmt.Errorf("request interceptor failed: %v", err)
-			return
-		}
+	if len(encoded) != 60 {
+		return nil, &InvalidAddressError{address, "wrong length"}
 	}
-	resp, err := http.DefaultClient.Do(req)
+	publicKey, err := base32Decode(encoded[:52])
 	if err != nil {
-		return
+		return nil, &InvalidAddressError{address, err.Error()}
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		err = fmt.Errorf("received unexpected HTTP return code %d", resp.StatusCode)
-		return
+	if publicKey.BitLen() > 256 {
+		return nil, &InvalidAddressError{address, "public key is too large"}
 	}
-	return ioutil.ReadAll(resp.Body)
-}
-
-func getPublicKeyFromAddress(address string) (*big.Int, error) {
-	if len(address) == 64 {
-		return base32Decode(address[4:56])
-	} else if len(address) == 65 {
-		return base32Decode(address[5:57])
+	if _, err = base32Decode(encoded[52:]); err != nil {
+		return nil, &InvalidAddressError{address, err.Error()}
+	}
+	checksum, err := getAddressChecksum(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if encoded[52:] != checksum {
+		return nil, &InvalidAddressError{address, "checksum mismatch"}
 	}
-	return nil, fmt.Errorf("could not parse address %s", address)
+	return publicKey, nil
 }
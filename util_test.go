@@ -0,0 +1,48 @@
+package atto
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateAddress(t *testing.T) {
+	valid := []string{
+		"nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh",
+		"xrb_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh",
+		"nano_1111111111111111111111111111111111111111111111111111hifc8npp",
+	}
+	for _, address := range valid {
+		if err := ValidateAddress(address); err != nil {
+			t.Errorf("expected '%s' to be valid, got %v", address, err)
+		}
+	}
+	invalid := []string{
+		"",
+		"nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnx",
+		"nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnn",
+		"nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnhh",
+		"nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5ht0nh",
+		"nano_4cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh",
+		"nona_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh",
+	}
+	for _, address := range invalid {
+		if err := ValidateAddress(address); !errors.Is(err, ErrInvalidAddress) {
+			t.Errorf("expected '%s' to be invalid, got %v", address, err)
+		}
+	}
+}
+
+func TestGetAddressRoundTrip(t *testing.T) {
+	address := "nano_1i7wsbehgwhxct91wpojr1j588ydikd64uc7p3kj54nofqioc6ydjopezf13"
+	account, err := NewAccountFromAddress(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := getAddress(account.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != address {
+		t.Errorf("expected %s, got %s", address, got)
+	}
+}
@@ -2,71 +2,255 @@ package atto
 
 import (
 	"context"
-	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"sync/atomic"
+	"time"
 
 	"github.com/klauspost/cpuid/v2"
-	"golang.org/x/crypto/blake2b"
 )
 
 // Using cpuid.CPU.LogicalCores seems to yield the best performance.
 var workerRoutines = cpuid.CPU.LogicalCores
 
+// defaultProgressInterval is used, if WorkOptions.ProgressInterval is
+// not set.
+const defaultProgressInterval = time.Second
+
+// WorkOptions control the local generation of work. The zero value
+// uses all CPU cores without any limits.
+type WorkOptions struct {
+	// Workers is the number of goroutines searching for work. If it is
+	// not positive, one worker per logical CPU core is used.
+	Workers int
+
+	// Throttle is the fraction of time, that each worker pauses, to
+	// leave CPU time for other processes. It must be at least 0 and
+	// less than 1. For example, 0.75 reduces the CPU usage of each
+	// worker to roughly a quarter.
+	Throttle float64
+
+	// Difficulty is the minimum difficulty of the generated work. If
+	// it is lower than the threshold of the block, the threshold is
+	// used. See DifficultyFromMultiplier for prioritizing blocks with
+	// a higher difficulty.
+	Difficulty uint64
+
+	// MaxDuration limits the time spent searching for work. If it is
+	// exceeded, context.DeadlineExceeded is returned. If it is zero,
+	// the search is not limited.
+	MaxDuration time.Duration
+
+	// Progress is called regularly while the search is running. It
+	// must not block. It may be nil.
+	Progress func(WorkProgress)
+
+	// ProgressInterval is the time between calls of Progress. If it is
+	// zero, defaultProgressInterval is used.
+	ProgressInterval time.Duration
+}
+
+// WorkProgress describes the state of a running search for work.
+type WorkProgress struct {
+	// Hashes is the number of nonces tried so far.
+	Hashes uint64
+
+	// Elapsed is the time since the search started.
+	Elapsed time.Duration
+
+	// ExpectedHashes is the average number of hashes needed to find
+	// work for the threshold.
+	ExpectedHashes uint64
+
+	// Estimated is the time the whole search is expected to take,
+	// based on the hash rate so far. Since every nonce has the same
+	// chance of success, the search may well take longer.
+	Estimated time.Duration
+}
+
+// GenerateWorkForRoot generates work for the hexadecimal work root
+// hash, that reaches difficulty. The work root of a block is the hash
+// of its predecessor or, for the first block of an account, the
+// account's public key. options.Difficulty is ignored.
+func GenerateWorkForRoot(ctx context.Context, root string, difficulty uint64, options WorkOptions) (string, error) {
+	hash, err := decodeHash(root)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := findNonce(ctx, difficulty, hash, options)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%016x", nonce), nil
+}
+
+// WorkDifficulty computes the difficulty of work for the hexadecimal
+// work root hash.
+func WorkDifficulty(root, work string) (uint64, error) {
+	nonce, err := strconv.ParseUint(work, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse '%s' as work: %v", work, err)
+	}
+	hash, err := decodeHash(root)
+	if err != nil {
+		return 0, err
+	}
+	return workValue(nonce, hash)
+}
+
 type workerResult struct {
 	nonce uint64
 	err   error
 }
 
-func findNonce(workThreshold uint64, suffix []byte) (uint64, error) {
+// findNonce searches for a nonce that satisfies workThreshold. If ctx
+// is done before a nonce is found, all workers are stopped and
+// ctx.Err() is returned.
+func findNonce(ctx context.Context, workThreshold uint64, suffix []byte, options WorkOptions) (uint64, error) {
 	// See https://docs.nano.org/integration-guides/work-generation/#work-equation
 	// See https://docs.nano.org/protocol-design/spam-work-and-prioritization/#work-algorithm-details
+	workers := options.Workers
+	if workers <= 0 {
+		workers = workerRoutines
+	}
+	if options.Throttle < 0 || options.Throttle >= 1 {
+		return 0, fmt.Errorf("throttle %v is not within [0, 1)", options.Throttle)
+	}
+	if options.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.MaxDuration)
+		defer cancel()
+	}
 	results := make(chan workerResult)
-	ctx, cancel := context.WithCancel(context.Background())
-	for i := 0; i < workerRoutines; i++ {
-		go calculateHashes(workThreshold, suffix, uint64(i), results, ctx)
+	workerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	search := nonceSearch{
+		threshold: workThreshold,
+		suffix:    suffix,
+		step:      uint64(workers),
+		throttle:  options.Throttle,
+		results:   results,
+	}
+	for i := 0; i < workers; i++ {
+		go search.calculateHashes(workerCtx, uint64(i))
+	}
+	var ticks <-chan time.Time
+	if options.Progress != nil {
+		interval := options.ProgressInterval
+		if interval <= 0 {
+			interval = defaultProgressInterval
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+	start := time.Now()
+	for {
+		select {
+		case result := <-results:
+			return result.nonce, result.err
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-ticks:
+			options.Progress(search.progress(time.Since(start)))
+		}
 	}
-	result := <-results
-	stopWorkers(results, cancel)
-	return result.nonce, result.err
 }
 
-func stopWorkers(results chan workerResult, cancel context.CancelFunc) {
-	cancel()
+// nonceSearch holds the state shared by the workers of findNonce.
+type nonceSearch struct {
+	threshold uint64
+	suffix    []byte
+	step      uint64
+	throttle  float64
+	results   chan<- workerResult
 
-	// Empty channel, so that workers get ready to quit:
-	for {
-		select {
-		case <-results:
-		default:
-			return
+	hashes uint64 // Must only be accessed atomically.
+}
+
+// progress reports the progress of the search after elapsed time.
+func (s *nonceSearch) progress(elapsed time.Duration) WorkProgress {
+	p := WorkProgress{
+		Hashes:         atomic.LoadUint64(&s.hashes),
+		Elapsed:        elapsed,
+		ExpectedHashes: expectedHashes(s.threshold),
+	}
+	if p.Hashes > 0 {
+		rate := float64(p.Hashes) / elapsed.Seconds()
+		estimated := float64(p.ExpectedHashes) / rate * float64(time.Second)
+		if estimated >= math.MaxInt64 {
+			p.Estimated = math.MaxInt64
+		} else {
+			p.Estimated = time.Duration(estimated)
 		}
 	}
+	return p
 }
 
-func calculateHashes(workThreshold uint64, suffix []byte, nonce uint64, results chan workerResult, ctx context.Context) {
-	nonceBytes := make([]byte, 8)
-	hasher, err := blake2b.New(8, nil)
-	if err != nil {
-		results <- workerResult{err: err}
-		return
+// expectedHashes returns the average number of hashes needed to reach
+// threshold.
+func expectedHashes(threshold uint64) uint64 {
+	if threshold == 0 {
+		return 1
 	}
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		default:
-			binary.LittleEndian.PutUint64(nonceBytes, nonce)
-			_, err := hasher.Write(append(nonceBytes, suffix...))
-			if err != nil {
-				results <- workerResult{err: err}
+	expected := math.Ceil(math.Exp2(64) / float64(-threshold))
+	if expected >= math.Exp2(64) {
+		return math.MaxUint64
+	}
+	return uint64(expected)
+}
+
+// sendResult sends result to results, unless ctx is done first. This
+// ensures that workers never block forever after the search has ended.
+func sendResult(ctx context.Context, results chan<- workerResult, result workerResult) {
+	select {
+	case results <- result:
+	case <-ctx.Done():
+	}
+}
+
+// workValue computes the value of nonce for the work root hash, which
+// must reach the work threshold for the nonce to be valid.
+func workValue(nonce uint64, hash []byte) (uint64, error) {
+	if len(hash) != 32 {
+		return 0, fmt.Errorf("work root hash has %d instead of 32 bytes", len(hash))
+	}
+	return newWorkHasher(hash).value(nonce), nil
+}
+
+// workCheckInterval is the number of nonces a worker tries between
+// checks, whether the search has ended.
+const workCheckInterval = 1 << 12
+
+func (s *nonceSearch) calculateHashes(ctx context.Context, nonce uint64) {
+	hasher := newWorkHasher(s.suffix)
+	// The timer for the throttling pauses is reused, because a new timer
+	// for every chunk of nonces would create a lot of garbage.
+	var pauseTimer *time.Timer
+	if s.throttle > 0 {
+		pauseTimer = time.NewTimer(0)
+		defer pauseTimer.Stop()
+		<-pauseTimer.C
+	}
+	for ctx.Err() == nil {
+		start := time.Now()
+		for i := 0; i < workCheckInterval; i++ {
+			if hasher.value(nonce) >= s.threshold {
+				sendResult(ctx, s.results, workerResult{nonce: nonce})
 				return
 			}
-			hashBytes := hasher.Sum(nil)
-			hashNumber := binary.LittleEndian.Uint64(hashBytes)
-			if hashNumber >= workThreshold {
-				results <- workerResult{nonce: nonce}
+			nonce += s.step
+		}
+		atomic.AddUint64(&s.hashes, workCheckInterval)
+		if s.throttle > 0 {
+			busy := time.Since(start)
+			pause := time.Duration(float64(busy) * s.throttle / (1 - s.throttle))
+			pauseTimer.Reset(pause)
+			select {
+			case <-pauseTimer.C:
+			case <-ctx.Done():
 			}
-			hasher.Reset()
-			nonce += uint64(workerRoutines)
 		}
 	}
 }
@@ -0,0 +1,155 @@
+package atto
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// workInputSize is the size of the input for work hashes: an eight
+// byte nonce followed by the 32 byte work root hash.
+const workInputSize = 40
+
+// blake2bIV is the initialization vector of blake2b.
+var blake2bIV = [8]uint64{
+	0x6a09e667f3bcc908, 0xbb67ae8584caa73b, 0x3c6ef372fe94f82b, 0xa54ff53a5f1d36f1,
+	0x510e527fade682d1, 0x9b05688c2b3e6c1f, 0x1f83d9abfb41bd6b, 0x5be0cd19137e2179,
+}
+
+// blake2bSigma are the message word permutations of the blake2b
+// rounds.
+var blake2bSigma = [12][16]uint8{
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+	{14, 10, 4, 8, 9, 15, 13, 6, 1, 12, 0, 2, 11, 7, 5, 3},
+	{11, 8, 12, 0, 5, 2, 15, 13, 10, 14, 3, 6, 7, 1, 9, 4},
+	{7, 9, 3, 1, 13, 12, 11, 14, 2, 6, 5, 10, 4, 0, 15, 8},
+	{9, 0, 5, 7, 2, 4, 10, 15, 14, 1, 11, 12, 6, 8, 3, 13},
+	{2, 12, 6, 10, 0, 11, 8, 3, 4, 13, 7, 5, 15, 14, 1, 9},
+	{12, 5, 1, 15, 14, 13, 4, 10, 0, 7, 6, 3, 9, 2, 8, 11},
+	{13, 11, 7, 14, 12, 1, 3, 9, 5, 0, 15, 4, 8, 6, 2, 10},
+	{6, 15, 14, 9, 11, 3, 0, 8, 12, 2, 13, 7, 1, 4, 10, 5},
+	{10, 2, 8, 4, 7, 6, 1, 5, 15, 11, 9, 14, 3, 12, 13, 0},
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+	{14, 10, 4, 8, 9, 15, 13, 6, 1, 12, 0, 2, 11, 7, 5, 3},
+}
+
+// workHasher computes blake2b-8 hashes of a nonce followed by a fixed
+// work root hash. Since the 40 byte input always fits into a single
+// blake2b block, the hash is computed with a single call of the
+// compression function and without any allocations.
+type workHasher struct {
+	// buf holds the nonce, followed by the work root hash.
+	buf [workInputSize]byte
+
+	// m holds the message words of the blake2b block, decoded from
+	// buf. Only m[0], the nonce, changes between hashes.
+	m [16]uint64
+}
+
+// newWorkHasher creates a workHasher for the given work root hash,
+// which must be 32 bytes long.
+func newWorkHasher(hash []byte) *workHasher {
+	var w workHasher
+	copy(w.buf[8:], hash)
+	for i := 1; i < workInputSize/8; i++ {
+		w.m[i] = binary.LittleEndian.Uint64(w.buf[i*8:])
+	}
+	return &w
+}
+
+// value returns the work value of nonce, which is the blake2b-8 hash
+// of the nonce and the work root hash, interpreted as a little endian
+// number.
+func (w *workHasher) value(nonce uint64) uint64 {
+	m := &w.m
+	m[0] = nonce
+
+	// The parameter block sets a digest length of 8 bytes, no key, a
+	// fanout of 1 and a depth of 1.
+	h0 := blake2bIV[0] ^ 0x01010008
+	v0, v1, v2, v3 := h0, blake2bIV[1], blake2bIV[2], blake2bIV[3]
+	v4, v5, v6, v7 := blake2bIV[4], blake2bIV[5], blake2bIV[6], blake2bIV[7]
+	v8, v9, v10, v11 := blake2bIV[0], blake2bIV[1], blake2bIV[2], blake2bIV[3]
+	v12 := blake2bIV[4] ^ workInputSize // The byte counter.
+	v13 := blake2bIV[5]
+	v14 := ^blake2bIV[6] // The final block flag.
+	v15 := blake2bIV[7]
+
+	for i := range blake2bSigma {
+		s := &blake2bSigma[i]
+
+		v0 += v4 + m[s[0]]
+		v12 = bits.RotateLeft64(v12^v0, -32)
+		v8 += v12
+		v4 = bits.RotateLeft64(v4^v8, -24)
+		v0 += v4 + m[s[1]]
+		v12 = bits.RotateLeft64(v12^v0, -16)
+		v8 += v12
+		v4 = bits.RotateLeft64(v4^v8, -63)
+
+		v1 += v5 + m[s[2]]
+		v13 = bits.RotateLeft64(v13^v1, -32)
+		v9 += v13
+		v5 = bits.RotateLeft64(v5^v9, -24)
+		v1 += v5 + m[s[3]]
+		v13 = bits.RotateLeft64(v13^v1, -16)
+		v9 += v13
+		v5 = bits.RotateLeft64(v5^v9, -63)
+
+		v2 += v6 + m[s[4]]
+		v14 = bits.RotateLeft64(v14^v2, -32)
+		v10 += v14
+		v6 = bits.RotateLeft64(v6^v10, -24)
+		v2 += v6 + m[s[5]]
+		v14 = bits.RotateLeft64(v14^v2, -16)
+		v10 += v14
+		v6 = bits.RotateLeft64(v6^v10, -63)
+
+		v3 += v7 + m[s[6]]
+		v15 = bits.RotateLeft64(v15^v3, -32)
+		v11 += v15
+		v7 = bits.RotateLeft64(v7^v11, -24)
+		v3 += v7 + m[s[7]]
+		v15 = bits.RotateLeft64(v15^v3, -16)
+		v11 += v15
+		v7 = bits.RotateLeft64(v7^v11, -63)
+
+		v0 += v5 + m[s[8]]
+		v15 = bits.RotateLeft64(v15^v0, -32)
+		v10 += v15
+		v5 = bits.RotateLeft64(v5^v10, -24)
+		v0 += v5 + m[s[9]]
+		v15 = bits.RotateLeft64(v15^v0, -16)
+		v10 += v15
+		v5 = bits.RotateLeft64(v5^v10, -63)
+
+		v1 += v6 + m[s[10]]
+		v12 = bits.RotateLeft64(v12^v1, -32)
+		v11 += v12
+		v6 = bits.RotateLeft64(v6^v11, -24)
+		v1 += v6 + m[s[11]]
+		v12 = bits.RotateLeft64(v12^v1, -16)
+		v11 += v12
+		v6 = bits.RotateLeft64(v6^v11, -63)
+
+		v2 += v7 + m[s[12]]
+		v13 = bits.RotateLeft64(v13^v2, -32)
+		v8 += v13
+		v7 = bits.RotateLeft64(v7^v8, -24)
+		v2 += v7 + m[s[13]]
+		v13 = bits.RotateLeft64(v13^v2, -16)
+		v8 += v13
+		v7 = bits.RotateLeft64(v7^v8, -63)
+
+		v3 += v4 + m[s[14]]
+		v14 = bits.RotateLeft64(v14^v3, -32)
+		v9 += v14
+		v4 = bits.RotateLeft64(v4^v9, -24)
+		v3 += v4 + m[s[15]]
+		v14 = bits.RotateLeft64(v14^v3, -16)
+		v9 += v14
+		v4 = bits.RotateLeft64(v4^v9, -63)
+	}
+
+	// Only the first eight bytes of the digest are needed.
+	return h0 ^ v0 ^ v8
+}
@@ -0,0 +1,248 @@
+package atto
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// WorkProvider is used to obtain the work for blocks. Implementations
+// must only set block.Work if it reaches the required threshold.
+type WorkProvider interface {
+	ProvideWork(ctx context.Context, block *Block) error
+}
+
+// DifficultyWorkProvider is implemented by WorkProviders, which can
+// provide work of a higher difficulty than the threshold of a block.
+// All WorkProviders of this package implement it.
+type DifficultyWorkProvider interface {
+	WorkProvider
+
+	// ProvideWorkDifficulty is like ProvideWork, but the work must
+	// reach difficulty, if it is higher than the threshold of block.
+	ProvideWorkDifficulty(ctx context.Context, block *Block, difficulty uint64) error
+}
+
+// provideWorkDifficulty uses provider to obtain work, that reaches
+// difficulty. If provider does not implement DifficultyWorkProvider,
+// its work is used only if it happens to reach difficulty.
+func provideWorkDifficulty(ctx context.Context, provider WorkProvider, block *Block, difficulty uint64) error {
+	if p, ok := provider.(DifficultyWorkProvider); ok {
+		return p.ProvideWorkDifficulty(ctx, block, difficulty)
+	}
+	candidate := *block
+	if err := provider.ProvideWork(ctx, &candidate); err != nil {
+		return err
+	}
+	if err := candidate.validateWorkDifficulty(difficulty); err != nil {
+		return err
+	}
+	block.Work = candidate.Work
+	return nil
+}
+
+// LocalWorkProvider generates work using the CPU of the local computer.
+type LocalWorkProvider struct {
+	// Options control the CPU usage of the work generation. The zero
+	// value uses all CPU cores.
+	Options WorkOptions
+}
+
+// ProvideWork generates and sets the work of block.
+func (p LocalWorkProvider) ProvideWork(ctx context.Context, block *Block) error {
+	return block.GenerateWorkOptions(ctx, p.Options)
+}
+
+// ProvideWorkDifficulty generates work, that reaches difficulty, and
+// sets it as the work of block.
+func (p LocalWorkProvider) ProvideWorkDifficulty(ctx context.Context, block *Block, difficulty uint64) error {
+	options := p.Options
+	if difficulty > options.Difficulty {
+		options.Difficulty = difficulty
+	}
+	return block.GenerateWorkOptions(ctx, options)
+}
+
+// ClientWorkProvider fetches work using the work_generate RPC. Client
+// may point to a node or any other server implementing the action,
+// like the nano-work-server.
+type ClientWorkProvider struct {
+	Client *Client
+}
+
+// ProvideWork fetches, validates and sets the work of block.
+func (p ClientWorkProvider) ProvideWork(ctx context.Context, block *Block) error {
+	return p.Client.FetchWorkContext(ctx, block)
+}
+
+// ProvideWorkDifficulty fetches, validates and sets work, that reaches
+// difficulty.
+func (p ClientWorkProvider) ProvideWorkDifficulty(ctx context.Context, block *Block, difficulty uint64) error {
+	return p.Client.FetchWorkDifficultyContext(ctx, block, difficulty)
+}
+
+// CommandWorkProvider runs an external command to obtain work. The
+// work root hash and the difficulty threshold are appended to Args as
+// hexadecimal strings. The command must print the work to its standard
+// output.
+type CommandWorkProvider struct {
+	Name string
+	Args []string
+}
+
+// ProvideWork runs the command, validates its output and sets the
+// work of block.
+func (p CommandWorkProvider) ProvideWork(ctx context.Context, block *Block) error {
+	return p.ProvideWorkDifficulty(ctx, block, 0)
+}
+
+// ProvideWorkDifficulty is like ProvideWork, but passes difficulty to
+// the command, if it is higher than the threshold of block.
+func (p CommandWorkProvider) ProvideWorkDifficulty(ctx context.Context, block *Block, difficulty uint64) error {
+	hash, err := block.workHash()
+	if err != nil {
+		return err
+	}
+	difficulty = block.minimumDifficulty(difficulty)
+	args := append(append([]string{}, p.Args...), hash, fmt.Sprintf("%016x", difficulty))
+	out, err := exec.CommandContext(ctx, p.Name, args...).Output()
+	if err != nil {
+		return fmt.Errorf("work command failed: %v", err)
+	}
+	candidate := *block
+	candidate.Work = strings.TrimSpace(string(out))
+	if err = candidate.validateWorkDifficulty(difficulty); err != nil {
+		return err
+	}
+	block.Work = candidate.Work
+	return nil
+}
+
+// FallbackWorkProvider tries Providers in order until one of them
+// succeeds.
+type FallbackWorkProvider struct {
+	Providers []WorkProvider
+
+	// OnFailure is called whenever one of Providers fails, except
+	// for the last one. It may be nil.
+	OnFailure func(provider WorkProvider, err error)
+}
+
+// ProvideWork sets the work of block using the first provider that
+// succeeds. If all fail, the error of the last one is returned.
+func (p FallbackWorkProvider) ProvideWork(ctx context.Context, block *Block) error {
+	return p.ProvideWorkDifficulty(ctx, block, 0)
+}
+
+// ProvideWorkDifficulty is like ProvideWork, but the work must reach
+// difficulty.
+func (p FallbackWorkProvider) ProvideWorkDifficulty(ctx context.Context, block *Block, difficulty uint64) error {
+	err := fmt.Errorf("no work providers given")
+	for i, provider := range p.Providers {
+		if err = provideWorkDifficulty(ctx, provider, block, difficulty); err == nil {
+			return nil
+		} else if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if p.OnFailure != nil && i < len(p.Providers)-1 {
+			p.OnFailure(provider, err)
+		}
+	}
+	return err
+}
+
+// RaceWorkProvider runs all Providers concurrently. The first valid
+// result is used and the remaining providers are cancelled.
+type RaceWorkProvider struct {
+	Providers []WorkProvider
+}
+
+type raceResult struct {
+	work string
+	err  error
+}
+
+// ProvideWork sets the work of block to the first valid result. If all
+// providers fail, the error of the last failing one is returned.
+func (p RaceWorkProvider) ProvideWork(ctx context.Context, block *Block) error {
+	return p.ProvideWorkDifficulty(ctx, block, 0)
+}
+
+// ProvideWorkDifficulty is like ProvideWork, but the work must reach
+// difficulty.
+func (p RaceWorkProvider) ProvideWorkDifficulty(ctx context.Context, block *Block, difficulty uint64) error {
+	if len(p.Providers) == 0 {
+		return fmt.Errorf("no work providers given")
+	}
+	raceCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan raceResult, len(p.Providers))
+	for _, provider := range p.Providers {
+		// The copy is made before starting the goroutine, because block
+		// is modified as soon as the first result arrives.
+		go func(provider WorkProvider, candidate Block) {
+			err := provideWorkDifficulty(raceCtx, provider, &candidate, difficulty)
+			if err == nil {
+				err = candidate.validateWorkDifficulty(difficulty)
+			}
+			results <- raceResult{candidate.Work, err}
+		}(provider, *block)
+	}
+	var err error
+	for range p.Providers {
+		result := <-results
+		if result.err == nil {
+			block.Work = result.work
+			return nil
+		}
+		err = result.err
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// ProvideWorkConcurrently sets the work of all blocks using provider.
+// The work of at most concurrency blocks is obtained at the same time;
+// if concurrency is not positive, all blocks are handled at once.
+//
+// This is possible even for consecutive blocks of the same chain,
+// since the work root of a block is the hash of its predecessor, which
+// is known as soon as the predecessor has been created. If obtaining
+// the work for any block fails, the remaining work is cancelled and
+// the first error is returned.
+func ProvideWorkConcurrently(ctx context.Context, provider WorkProvider, blocks []*Block, concurrency int) error {
+	if concurrency <= 0 || concurrency > len(blocks) {
+		concurrency = len(blocks)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	jobs := make(chan *Block)
+	errs := make(chan error, concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			var err error
+			for block := range jobs {
+				if err == nil {
+					if err = provider.ProvideWork(ctx, block); err != nil {
+						cancel()
+					}
+				}
+			}
+			errs <- err
+		}()
+	}
+	for _, block := range blocks {
+		jobs <- block
+	}
+	close(jobs)
+	var err error
+	for i := 0; i < concurrency; i++ {
+		if workerErr := <-errs; workerErr != nil && (err == nil || err == context.Canceled) {
+			err = workerErr
+		}
+	}
+	return err
+}
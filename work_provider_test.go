@@ -0,0 +1,198 @@
+package atto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingWorkProvider waits until its context is done and counts the
+// cancellations in cancelled.
+type blockingWorkProvider struct {
+	cancelled *sync.WaitGroup
+}
+
+func (p blockingWorkProvider) ProvideWork(ctx context.Context, block *Block) error {
+	<-ctx.Done()
+	p.cancelled.Done()
+	return ctx.Err()
+}
+
+// failingWorkProvider always fails with err.
+type failingWorkProvider struct {
+	err error
+}
+
+func (p failingWorkProvider) ProvideWork(ctx context.Context, block *Block) error {
+	return p.err
+}
+
+// loggingWorkProvider appends name to log before delegating to
+// provider.
+type loggingWorkProvider struct {
+	name     string
+	log      *[]string
+	provider WorkProvider
+}
+
+func (p loggingWorkProvider) ProvideWork(ctx context.Context, block *Block) error {
+	*p.log = append(*p.log, p.name)
+	return p.provider.ProvideWork(ctx, block)
+}
+
+// waitTimeout waits for wg, but fails t after a generous timeout.
+func waitTimeout(t *testing.T, wg *sync.WaitGroup, what string) {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("%s did not happen", what)
+	}
+}
+
+func TestRaceWorkProvider(t *testing.T) {
+	var cancelled sync.WaitGroup
+	cancelled.Add(2)
+	provider := RaceWorkProvider{Providers: []WorkProvider{
+		blockingWorkProvider{&cancelled},
+		fixedWorkProvider{"0000000000000000"}, // Does not reach the threshold.
+		failingWorkProvider{fmt.Errorf("unreachable")},
+		fixedWorkProvider{testWork},
+		blockingWorkProvider{&cancelled},
+	}}
+	block := testWorkBlock
+	if err := provider.ProvideWork(context.Background(), &block); err != nil {
+		t.Fatal(err)
+	}
+	if block.Work != testWork {
+		t.Errorf("expected work %s, got %s", testWork, block.Work)
+	}
+	waitTimeout(t, &cancelled, "cancelling the losing providers")
+
+	provider.Providers = []WorkProvider{
+		failingWorkProvider{fmt.Errorf("unreachable")},
+		fixedWorkProvider{"0000000000000000"},
+	}
+	block = testWorkBlock
+	if err := provider.ProvideWork(context.Background(), &block); err == nil || block.Work != "" {
+		t.Errorf("expected error without work, got work '%s'", block.Work)
+	}
+}
+
+func TestFallbackWorkProvider(t *testing.T) {
+	var log []string
+	failure := fmt.Errorf("unreachable")
+	var failed []error
+	provider := FallbackWorkProvider{
+		Providers: []WorkProvider{
+			loggingWorkProvider{"failing", &log, failingWorkProvider{failure}},
+			loggingWorkProvider{"insufficient", &log, fixedWorkProvider{"0000000000000000"}},
+			loggingWorkProvider{"valid", &log, fixedWorkProvider{testWork}},
+			loggingWorkProvider{"unused", &log, fixedWorkProvider{testWork}},
+		},
+		OnFailure: func(provider WorkProvider, err error) { failed = append(failed, err) },
+	}
+	block := testWorkBlock
+	if err := provider.ProvideWork(context.Background(), &block); err != nil {
+		t.Fatal(err)
+	}
+	if block.Work != testWork {
+		t.Errorf("expected work %s, got %s", testWork, block.Work)
+	}
+	if fmt.Sprint(log) != "[failing insufficient valid]" {
+		t.Errorf("unexpected order of providers %v", log)
+	}
+	if len(failed) != 2 || failed[0] != failure || !errors.Is(failed[1], ErrInsufficientWork) {
+		t.Errorf("unexpected failures %v", failed)
+	}
+
+	// OnFailure is not called for the last provider; its error is
+	// returned.
+	failed = nil
+	provider.Providers = provider.Providers[:2]
+	block = testWorkBlock
+	if err := provider.ProvideWork(context.Background(), &block); !errors.Is(err, ErrInsufficientWork) {
+		t.Errorf("expected %v, got %v", ErrInsufficientWork, err)
+	}
+	if len(failed) != 1 || block.Work != "" {
+		t.Errorf("expected one failure and no work, got %v and '%s'", failed, block.Work)
+	}
+}
+
+func TestCommandWorkProvider(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	// The script checks the appended work root and difficulty, which
+	// become $1 and $2.
+	script := func(output string) CommandWorkProvider {
+		return CommandWorkProvider{Name: "sh", Args: []string{"-c", fmt.Sprintf(
+			`test "$1" = %s && test "$2" = fffffff800000000 && echo "%s"`,
+			testWorkBlock.Previous, output), "sh"}}
+	}
+	block := testWorkBlock
+	if err := script(testWork).ProvideWork(context.Background(), &block); err != nil {
+		t.Fatal(err)
+	}
+	if block.Work != testWork {
+		t.Errorf("expected work %s, got %s", testWork, block.Work)
+	}
+
+	for _, output := range []string{"not work", "0000000000000000"} {
+		block = testWorkBlock
+		if err := script(output).ProvideWork(context.Background(), &block); err == nil || block.Work != "" {
+			t.Errorf("expected error without work for output '%s', got work '%s'", output, block.Work)
+		}
+	}
+
+	// testWork does not reach the requested difficulty.
+	block = testWorkBlock
+	provider := CommandWorkProvider{Name: "sh", Args: []string{"-c", "echo " + testWork}}
+	err := provider.ProvideWorkDifficulty(context.Background(), &block, 0xffffffffffff0000)
+	if !errors.Is(err, ErrInsufficientWork) || block.Work != "" {
+		t.Errorf("expected %v without work, got %v and '%s'", ErrInsufficientWork, err, block.Work)
+	}
+}
+
+// chainWorkProvider fails for the block with the previous hash failFor
+// and waits for cancellation for all other blocks.
+type chainWorkProvider struct {
+	failFor string
+	err     error
+}
+
+func (p chainWorkProvider) ProvideWork(ctx context.Context, block *Block) error {
+	if block.Previous == p.failFor {
+		return p.err
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestProvideWorkConcurrentlyCancel(t *testing.T) {
+	blocks := make([]*Block, 3)
+	for i := range blocks {
+		blocks[i] = &Block{Previous: fmt.Sprintf("%064X", i)}
+	}
+	failure := fmt.Errorf("unreachable")
+	provider := chainWorkProvider{blocks[1].Previous, failure}
+	errs := make(chan error)
+	go func() { errs <- ProvideWorkConcurrently(context.Background(), provider, blocks, 0) }()
+	// The other blocks only return, once they are cancelled.
+	select {
+	case err := <-errs:
+		if err != failure {
+			t.Errorf("expected %v, got %v", failure, err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("the other blocks were not cancelled")
+	}
+}
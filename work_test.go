@@ -1,11 +1,224 @@
 package atto
 
 import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
 	"testing"
+	"time"
+
+	"golang.org/x/crypto/blake2b"
 )
 
 func BenchmarkNonceSearch(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		findNonce(0xffffff0000000000, nil)
+		findNonce(context.Background(), 0xffffff0000000000, nil, WorkOptions{})
+	}
+}
+
+// referenceWorkValue computes the work value with the generic blake2b
+// implementation, like the work engine used to.
+func referenceWorkValue(nonce uint64, hash []byte) uint64 {
+	hasher, _ := blake2b.New(8, nil)
+	nonceBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(nonceBytes, nonce)
+	hasher.Write(append(nonceBytes, hash...))
+	return binary.LittleEndian.Uint64(hasher.Sum(nil))
+}
+
+func BenchmarkWorkValue(b *testing.B) {
+	hasher := newWorkHasher(make([]byte, 32))
+	b.ReportAllocs()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		hasher.value(uint64(i))
+	}
+	b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "hashes/s")
+}
+
+func BenchmarkWorkValueReference(b *testing.B) {
+	hash := make([]byte, 32)
+	b.ReportAllocs()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		referenceWorkValue(uint64(i), hash)
+	}
+	b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "hashes/s")
+}
+
+func TestWorkValue(t *testing.T) {
+	hash := make([]byte, 32)
+	for i := 0; i < 1000; i++ {
+		rand.Read(hash)
+		nonce := rand.Uint64()
+		value, err := workValue(nonce, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := referenceWorkValue(nonce, hash); value != expected {
+			t.Fatalf("expected %016x for nonce %016x and hash %X, got %016x", expected, nonce, hash, value)
+		}
+	}
+	if allocs := testing.AllocsPerRun(100, func() { newWorkHasher(hash).value(1) }); allocs > 0 {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+}
+
+func TestFindNonceCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := findNonce(ctx, 0xffffffffffffffff, nil, WorkOptions{})
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestBlockDifficulty(t *testing.T) {
+	block := Block{
+		Account:  "nano_3cyb3rwp5ba47t5jdzm5o7apeduppsgzw8ockn1dqt4xcqgapta6gh5htnnh",
+		Previous: "991CF190094C00F0B68E2E5F75F6BEE95A2E0BD93CEAA4A6734DB9F19B728948",
+		SubType:  SubTypeSend,
+	}
+	if err := block.ValidateWork(); err != ErrWorkMissing {
+		t.Errorf("expected %v, got %v", ErrWorkMissing, err)
+	}
+	hash, _ := hex.DecodeString(block.Previous)
+	var threshold uint64 = 0xff00000000000000
+	nonce, err := findNonce(context.Background(), threshold, hash, WorkOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.Work = fmt.Sprintf("%016x", nonce)
+	difficulty, err := block.Difficulty()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if difficulty < threshold {
+		t.Errorf("difficulty %016x is below threshold %016x", difficulty, threshold)
+	}
+	err = block.ValidateWork()
+	if difficulty < DefaultWorkThreshold && !errors.Is(err, ErrInsufficientWork) {
+		t.Errorf("expected %v, got %v", ErrInsufficientWork, err)
+	}
+}
+
+// countingWorkProvider records how many blocks it handles at once.
+type countingWorkProvider struct {
+	mu         sync.Mutex
+	active     int
+	maxActive  int
+	failOnWork string
+}
+
+func (p *countingWorkProvider) ProvideWork(ctx context.Context, block *Block) error {
+	p.mu.Lock()
+	p.active++
+	if p.active > p.maxActive {
+		p.maxActive = p.active
+	}
+	p.mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	p.mu.Lock()
+	p.active--
+	p.mu.Unlock()
+	if block.Previous == p.failOnWork {
+		return fmt.Errorf("failed")
+	}
+	block.Work = "0000000000000000"
+	return nil
+}
+
+func TestProvideWorkConcurrently(t *testing.T) {
+	blocks := make([]*Block, 10)
+	for i := range blocks {
+		blocks[i] = &Block{Previous: fmt.Sprintf("%064X", i)}
+	}
+	provider := &countingWorkProvider{}
+	if err := ProvideWorkConcurrently(context.Background(), provider, blocks, 3); err != nil {
+		t.Fatal(err)
+	}
+	if provider.maxActive > 3 {
+		t.Errorf("expected at most 3 concurrent blocks, got %d", provider.maxActive)
+	}
+	for i, block := range blocks {
+		if block.Work == "" {
+			t.Errorf("block %d is missing its work", i)
+		}
+	}
+
+	provider = &countingWorkProvider{failOnWork: blocks[4].Previous}
+	if err := ProvideWorkConcurrently(context.Background(), provider, blocks, 3); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestFindNonceOptions(t *testing.T) {
+	// No nonce reaches the threshold, so the search ends with the
+	// deadline.
+	options := WorkOptions{Workers: 2, Throttle: 0.5, MaxDuration: time.Millisecond}
+	_, err := findNonce(context.Background(), 0xffffffffffffffff, nil, options)
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	options = WorkOptions{Throttle: 1}
+	if _, err = findNonce(context.Background(), 0, nil, options); err == nil {
+		t.Error("expected error for throttle of 1")
+	}
+}
+
+func TestFindNonceProgress(t *testing.T) {
+	// No nonce reaches the threshold, so the search only ends, when
+	// Progress cancels it after enough hashes.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var progress []WorkProgress
+	options := WorkOptions{
+		Workers: 1,
+		Progress: func(p WorkProgress) {
+			progress = append(progress, p)
+			if p.Hashes >= 2*workCheckInterval {
+				cancel()
+			}
+		},
+		ProgressInterval: time.Millisecond,
+	}
+	if _, err := findNonce(ctx, 0xffffffffffffffff, make([]byte, 32), options); err != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+	// Progress is only called by findNonce, which has returned.
+	if len(progress) == 0 {
+		t.Fatal("progress was never reported")
+	}
+	for i := 1; i < len(progress); i++ {
+		if progress[i].Hashes < progress[i-1].Hashes || progress[i].Elapsed <= progress[i-1].Elapsed {
+			t.Errorf("progress went backwards from %+v to %+v", progress[i-1], progress[i])
+		}
+	}
+	last := progress[len(progress)-1]
+	if last.Hashes < 2*workCheckInterval || last.ExpectedHashes != 1<<64-1 || last.Estimated <= 0 {
+		t.Errorf("unexpected progress %+v", last)
+	}
+}
+
+func TestWorkProgress(t *testing.T) {
+	search := nonceSearch{threshold: 0xfffffff800000000}
+	p := search.progress(time.Second)
+	if p.Hashes != 0 || p.Elapsed != time.Second || p.ExpectedHashes != 1<<29 || p.Estimated != 0 {
+		t.Errorf("unexpected progress before the first hash %+v", p)
+	}
+	search.hashes = 1 << 27
+	p = search.progress(2 * time.Second)
+	if p.Hashes != 1<<27 || p.ExpectedHashes != 1<<29 || p.Estimated != 8*time.Second {
+		t.Errorf("unexpected progress %+v", p)
+	}
+	search = nonceSearch{threshold: 0xffffffffffffffff, hashes: 1}
+	if p = search.progress(time.Second); p.ExpectedHashes != 1<<64-1 || p.Estimated != math.MaxInt64 {
+		t.Errorf("unexpected progress for the highest threshold %+v", p)
 	}
 }